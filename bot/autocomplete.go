@@ -0,0 +1,70 @@
+package bot
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// maxAutocompleteChoices is the maximum number of choices Discord accepts in an autocomplete result.
+const maxAutocompleteChoices = 25
+
+// handleStoreAutocomplete suggests the configured stores while the user types the "store" option.
+// The stores are matched by ID or display name against what the user has typed so far.
+func handleStoreAutocomplete(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	option := i.ApplicationCommandData().GetOption("store")
+
+	choices := []*discordgo.ApplicationCommandOptionChoice{}
+	for _, store := range Stores.Search(option.StringValue()) {
+		if len(choices) == maxAutocompleteChoices {
+			break
+		}
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+			Name:  store.Name,
+			Value: store.ID,
+		})
+	}
+
+	respondAutocomplete(s, i, choices)
+}
+
+// handleTicketAutocomplete suggests the active watches of the user while they type the "ticket" option.
+// The value of each choice is "storeID:ticketNumber", the key of the watch without the user ID.
+func handleTicketAutocomplete(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	option := i.ApplicationCommandData().GetOption("ticket")
+
+	choices := []*discordgo.ApplicationCommandOptionChoice{}
+	for _, key := range GetUserWatchKeys(getInteractionUserID(i)) {
+		if len(choices) == maxAutocompleteChoices {
+			break
+		}
+		storeName := key.StoreID
+		if store, err := Stores.Get(key.StoreID); err == nil {
+			storeName = store.Name
+		}
+		name := fmt.Sprintf("%s #%d", storeName, key.TicketNumber)
+		if !strings.Contains(strings.ToLower(name), strings.ToLower(option.StringValue())) {
+			continue
+		}
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+			Name:  name,
+			Value: fmt.Sprintf("%s:%d", key.StoreID, key.TicketNumber),
+		})
+	}
+
+	respondAutocomplete(s, i, choices)
+}
+
+func respondAutocomplete(s *discordgo.Session, i *discordgo.InteractionCreate, choices []*discordgo.ApplicationCommandOptionChoice) {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionApplicationCommandAutocompleteResult,
+		Data: &discordgo.InteractionResponseData{
+			Choices: choices,
+		},
+	})
+	if err != nil {
+		slog.Error("Error responding to autocomplete", "error", err)
+	}
+}
@@ -3,33 +3,61 @@ package bot
 import (
 	"fmt"
 	"log"
+	"log/slog"
 	"os"
 	"os/signal"
 	"time"
 
+	"github.com/SDxBacon/gido-guardian-bot/gido"
 	"github.com/bwmarrin/discordgo"
 )
 
 var (
 	Commands = map[string]string{
-		"WaitInfo":     "wait-info",
-		"Watching":     "watching",
-		"StopWatching": "stop-watching",
-		"CleanGido":    "clean-gido",
+		"WaitInfo":       "wait-info",
+		"Watching":       "watching",
+		"StopWatching":   "stop-watching",
+		"CleanGido":      "clean-gido",
+		"GidoStats":      "gido-stats",
+		"NotifyDelivery": "notify-delivery",
+		"MyWatches":      "my-watches",
+		"Watches":        "watches",
 	}
 )
 
+// storeOption is the optional "store" option shared by the commands following a store.
+var storeOption = &discordgo.ApplicationCommandOption{
+	Type:         discordgo.ApplicationCommandOptionString,
+	Name:         "store",
+	Description:  "The store to follow, defaults to the first configured store",
+	Required:     false,
+	Autocomplete: true,
+}
+
 var (
-	AppID    string = "1292493286681870377"
-	Token    string = "YOUR_BOT_TOKEN_HERE"
-	GuildID  string = ""
-	BotID    string
-	commands = []*discordgo.ApplicationCommand{
-		{
+	Token string = "YOUR_BOT_TOKEN_HERE"
+	// GuildIDs are the guilds the commands are registered in; they are registered globally if empty.
+	GuildIDs []string
+	// BotID is the user ID of the bot, set once connected, which is also the application ID the commands are registered with.
+	BotID string
+)
+
+// commandRoutes are the slash commands of the bot along with their handlers.
+// Adding a command only takes a new entry, which onReady registers and the router dispatches.
+var commandRoutes = []commandRoute{
+	{
+		command: &discordgo.ApplicationCommand{
 			Name:        Commands["WaitInfo"],
 			Description: "Fetch the wait info of Gido",
+			Options: []*discordgo.ApplicationCommandOption{
+				storeOption,
+			},
 		},
-		{
+		handler:   handleWaitInfoInteraction,
+		ephemeral: true,
+	},
+	{
+		command: &discordgo.ApplicationCommand{
 			Name:        Commands["Watching"],
 			Description: "Start watching for a specific ticket number",
 			Options: []*discordgo.ApplicationCommandOption{
@@ -39,32 +67,206 @@ var (
 					Description: "The ticket number to watch for",
 					Required:    true,
 				},
+				storeOption,
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "alert-groups",
+					Description: "Notify me when this many groups remain, e.g. 10,5,2",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "alert-minutes",
+					Description: "Notify me when the estimated wait drops to these minutes, e.g. 30,15",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "notify",
+					Description: "When to post progress updates, defaults to when the number changes",
+					Required:    false,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "When the number changes", Value: gido.NotifyOnChange.String()},
+						{Name: "Every N minutes", Value: gido.NotifyEvery.String()},
+						{Name: "Digest every N minutes", Value: gido.NotifyDigest.String()},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "notify-minutes",
+					Description: "The N minutes of the every and digest notify modes, defaults to 10",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "poll-seconds",
+					Description: "How often to check the queue, defaults to faster as the ticket gets closer",
+					Required:    false,
+				},
 			},
 		},
-		{
+		handler:   handleWatchingInteraction,
+		ephemeral: true,
+	},
+	{
+		command: &discordgo.ApplicationCommand{
 			Name:        Commands["StopWatching"],
 			Description: "Stop watching for ticket numbers",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:         discordgo.ApplicationCommandOptionString,
+					Name:         "ticket",
+					Description:  "The watch to stop, required when watching several tickets",
+					Required:     false,
+					Autocomplete: true,
+				},
+			},
 		},
-		{
+		handler:   handleStopWatchingInteraction,
+		ephemeral: true,
+	},
+	{
+		command: &discordgo.ApplicationCommand{
+			Name:        Commands["MyWatches"],
+			Description: "List the tickets you are watching",
+		},
+		handler:   handleMyWatchesInteraction,
+		ephemeral: true,
+	},
+	{
+		command: &discordgo.ApplicationCommand{
+			Name:        Commands["Watches"],
+			Description: "List every ticket watched in this server",
+			// Restricted to admins, see checkAdmin
+			DefaultMemberPermissions: &adminPermissions,
+		},
+		handler:   handleWatchesInteraction,
+		ephemeral: true,
+	},
+	{
+		command: &discordgo.ApplicationCommand{
 			Name:        Commands["CleanGido"],
 			Description: "Delete all messages sent by the bot in this channel",
+			// Restricted to admins, see checkAdmin
+			DefaultMemberPermissions: &adminPermissions,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "max-age",
+					Description: "Only delete messages sent in the last given number of days",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "max-count",
+					Description: "Delete at most this many messages, newest first",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "dry-run",
+					Description: "Only count the messages that would be deleted",
+					Required:    false,
+				},
+			},
 		},
-	}
-)
+		handler:   handleCleanGidoInteraction,
+		ephemeral: true,
+	},
+	{
+		command: &discordgo.ApplicationCommand{
+			Name:        Commands["GidoStats"],
+			Description: "Show the typical queue length and throughput by weekday and hour",
+			Options: []*discordgo.ApplicationCommandOption{
+				storeOption,
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "days",
+					Description: "How many days of history to include, defaults to 28",
+					Required:    false,
+				},
+			},
+		},
+		handler:   handleGidoStatsInteraction,
+		ephemeral: false,
+	},
+	{
+		command: &discordgo.ApplicationCommand{
+			Name:        Commands["NotifyDelivery"],
+			Description: "Choose where your watch notifications are delivered",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "mode",
+					Description: "Where to deliver the notifications",
+					Required:    true,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "In the channel", Value: string(DeliveryChannel)},
+						{Name: "By direct message", Value: string(DeliveryDM)},
+						{Name: "Both", Value: string(DeliveryBoth)},
+					},
+				},
+			},
+		},
+		handler:   handleNotifyDeliveryInteraction,
+		ephemeral: true,
+	},
+}
+
+// Stores is the registry of stores the bot can follow; the first store is the default one.
+// Replace it before calling Run to follow other branches, or to read the wait info from
+// another endpoint or a fake source.
+var Stores = newDefaultStoreRegistry()
+
+// HistoryFile is where every polled wait info is recorded for /gido-stats.
+// Recording is disabled when it is empty.
+var HistoryFile = "history.jsonl"
+
+// recorder records the polled wait info of every store, created in Run from HistoryFile.
+var recorder *gido.Recorder
+
+func newDefaultStoreRegistry() *gido.StoreRegistry {
+	registry := gido.NewStoreRegistry(gido.HTTPSourceFactory())
+	registry.Register(gido.DefaultStore)
+	return registry
+}
 
 func Run() {
+	watchStore = NewWatchStore(WatchesFile)
+	preferenceStore = NewPreferenceStore(PreferencesFile)
+	if err := preferenceStore.Load(); err != nil {
+		fatalf("Error loading preferences: %v", err)
+	}
+
+	// record the queue history of every store
+	if HistoryFile != "" {
+		var err error
+		recorder, err = gido.NewRecorder(HistoryFile)
+		if err != nil {
+			fatalf("Error opening history file: %v", err)
+		}
+		defer recorder.Close()
+
+		for _, store := range Stores.List() {
+			poller, _ := Stores.Poller(store.ID)
+			detach := recorder.Attach(store.ID, poller)
+			defer detach()
+		}
+	}
+
 	// create a session
 	discord, err := discordgo.New("Bot " + Token)
 	if err != nil {
-		log.Fatal("Error message")
+		fatalf("Error creating the Discord session: %v", err)
 	}
 
 	// add a event handler
 	discord.AddHandler(onReady)
-	discord.AddHandler(handleWaitInfoInteraction)
-	discord.AddHandler(handleWatchingInteraction)
-	discord.AddHandler(handleStopWatchingInteraction)
-	discord.AddHandler(handleCleanGidoInteraction)
+	discord.AddHandler(newRouter(commandRoutes).handle)
+
+	// tell the watchers once per outage instead of on every failed poll
+	stopWatchingOutages := watchOutages(discord)
+	defer stopWatchingOutages()
 
 	// open session
 	discord.Open()
@@ -79,12 +281,35 @@ func Run() {
 	fmt.Println("Bot stopped....")
 }
 
+// fatalf logs an error keeping the bot from running at the error level, so it is shown
+// whatever the log level, and exits.
+func fatalf(format string, args ...any) {
+	slog.Error(fmt.Sprintf(format, args...))
+	os.Exit(1)
+}
+
 func onReady(s *discordgo.Session, event *discordgo.Ready) {
 	log.Printf("以 %v 身份登入", s.State.User.Username)
 	BotID = s.State.User.ID
 
+	// Register the commands globally, or in every configured guild
+	guildIDs := GuildIDs
+	if len(guildIDs) == 0 {
+		guildIDs = []string{""}
+	}
+	for _, guildID := range guildIDs {
+		registerCommands(s, guildID)
+	}
+
+	// Resume the watches that were running before the bot stopped
+	restoreWatches(s)
+}
+
+// registerCommands registers the commands of commandRoutes in the guild, or globally if
+// guildID is empty, updating the changed ones and deleting the ones no longer defined.
+func registerCommands(s *discordgo.Session, guildID string) {
 	// Get all existing commands from server
-	existingCommands, err := s.ApplicationCommands(s.State.User.ID, GuildID)
+	existingCommands, err := s.ApplicationCommands(BotID, guildID)
 	if err != nil {
 		log.Panicf("Getting existing commands with err:%v", err)
 		return
@@ -96,13 +321,15 @@ func onReady(s *discordgo.Session, event *discordgo.Ready) {
 		existingCommandMap[cmd.Name] = cmd
 	}
 
-	// Register or update commands based on local `commands` list
+	// Register or update commands based on local `commandRoutes` list
 	fmt.Printf("%s %s", time.Now().Format("2006/01/02 15:04:05"), "Registering Commands...")
-	for _, v := range commands {
+	for _, route := range commandRoutes {
+		v := route.command
+		localizeCommand(v)
 		existingCmd, exists := existingCommandMap[v.Name]
-		if !exists || existingCmd.Description != v.Description {
+		if !exists || isCommandChanged(existingCmd, v) {
 			// Command does not exist or description has changed; create or update
-			_, err := s.ApplicationCommandCreate(BotID, GuildID, v)
+			_, err := s.ApplicationCommandCreate(BotID, guildID, v)
 			if err != nil {
 				log.Panicf("Unable to register or update '%v' command: %v", v.Name, err)
 			}
@@ -112,12 +339,12 @@ func onReady(s *discordgo.Session, event *discordgo.Ready) {
 	}
 	fmt.Printf("[\033[32mOK\033[0m]\n")
 
-	// Delete extra commands that are not in the local `commands` list
+	// Delete extra commands that are not in the local `commandRoutes` list
 	fmt.Printf("%s %s", time.Now().Format("2006/01/02 15:04:05"), "Deleting Legacy Commands...")
 	for _, cmd := range existingCommandMap {
-		err := s.ApplicationCommandDelete(BotID, GuildID, cmd.ID)
+		err := s.ApplicationCommandDelete(BotID, guildID, cmd.ID)
 		if err != nil {
-			log.Printf("Failed to delete extra command '%v': %v", cmd.Name, err)
+			slog.Warn("Failed to delete extra command", "command", cmd.Name, "error", err)
 		} else {
 			log.Printf("Deleted extra command: %v", cmd.Name)
 		}
@@ -125,43 +352,56 @@ func onReady(s *discordgo.Session, event *discordgo.Ready) {
 	fmt.Printf("[\033[32mOK\033[0m]\n")
 }
 
-func cleanBotMessages(s *discordgo.Session, channelID string) (int, error) {
-	var deletedCount int
-	var lastMessageID string
-	for {
-		messages, err := s.ChannelMessages(channelID, 100, lastMessageID, "", "")
-		if err != nil {
-			return deletedCount, fmt.Errorf("獲取訊息失敗: %v", err)
-		}
-
-		if len(messages) == 0 {
-			break
-		}
-
-		var botMessages []string
-		for _, msg := range messages {
-			if msg.Author.ID == BotID {
-				botMessages = append(botMessages, msg.ID)
-			}
-			lastMessageID = msg.ID
+// isCommandChanged reports whether the registered command differs from the local definition
+// in its description, default member permissions, localizations or options.
+func isCommandChanged(existing, local *discordgo.ApplicationCommand) bool {
+	if existing.Description != local.Description || len(existing.Options) != len(local.Options) {
+		return true
+	}
+	if (existing.DefaultMemberPermissions == nil) != (local.DefaultMemberPermissions == nil) ||
+		(local.DefaultMemberPermissions != nil && *existing.DefaultMemberPermissions != *local.DefaultMemberPermissions) {
+		return true
+	}
+	if !sameLocalizations(existing.NameLocalizations, local.NameLocalizations) ||
+		!sameLocalizations(existing.DescriptionLocalizations, local.DescriptionLocalizations) {
+		return true
+	}
+	for idx, option := range local.Options {
+		existingOption := existing.Options[idx]
+		if existingOption.Name != option.Name ||
+			existingOption.Type != option.Type ||
+			existingOption.Description != option.Description ||
+			existingOption.Required != option.Required ||
+			existingOption.Autocomplete != option.Autocomplete ||
+			len(existingOption.Choices) != len(option.Choices) ||
+			!sameLocalizations(&existingOption.DescriptionLocalizations, &option.DescriptionLocalizations) {
+			return true
 		}
-
-		if len(botMessages) > 0 {
-			// FIXME: You can only bulk delete messages that are under 14 days old.
-			err = s.ChannelMessagesBulkDelete(channelID, botMessages)
-			if err != nil {
-				return deletedCount, fmt.Errorf("批量刪除訊息失敗: %v", err)
+		for choiceIdx, choice := range option.Choices {
+			if !sameLocalizations(&existingOption.Choices[choiceIdx].NameLocalizations, &choice.NameLocalizations) {
+				return true
 			}
-			deletedCount += len(botMessages)
 		}
+	}
+	return false
+}
 
-		if len(messages) < 100 {
-			break
+// sameLocalizations reports whether two localizations are equal, a missing one being equal to an empty one.
+func sameLocalizations(a, b *map[discordgo.Locale]string) bool {
+	var aMap, bMap map[discordgo.Locale]string
+	if a != nil {
+		aMap = *a
+	}
+	if b != nil {
+		bMap = *b
+	}
+	if len(aMap) != len(bMap) {
+		return false
+	}
+	for locale, text := range aMap {
+		if bMap[locale] != text {
+			return false
 		}
-
-		// 為了避免超過 Discord API 的速率限制，在每次批量刪除後稍作暫停
-		time.Sleep(1 * time.Second)
 	}
-
-	return deletedCount, nil
+	return true
 }
@@ -0,0 +1,191 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// bulkDeleteMaxAge is the age up to which messages can be bulk deleted. Discord rejects
+	// messages older than 14 days, an hour is kept as margin for clock skew and slow cleanups.
+	bulkDeleteMaxAge = 14*24*time.Hour - time.Hour
+	// bulkDeleteMaxCount is the maximum number of messages of a bulk delete request.
+	bulkDeleteMaxCount = 100
+	// singleDeletePause is the pause between two single deletes, on top of the rate limit
+	// handling of discordgo, as Discord is strict about deleting old messages.
+	singleDeletePause = 500 * time.Millisecond
+	// maxRateLimitRetries is how many times a single delete is retried after being rate limited.
+	maxRateLimitRetries = 5
+)
+
+// cleanOptions limits which bot messages cleanBotMessages deletes.
+type cleanOptions struct {
+	// MaxAge skips the messages older than MaxAge, or none if zero.
+	MaxAge time.Duration
+	// MaxCount stops after MaxCount messages, or never if zero.
+	MaxCount int
+	// DryRun only counts the messages that would be deleted.
+	DryRun bool
+}
+
+// cleanProgress is the progress of a cleanup.
+type cleanProgress struct {
+	// Scanned is the number of messages of the channel looked at.
+	Scanned int
+	// Matched is the number of bot messages to delete, split in Recent and Old by bulkDeleteMaxAge.
+	Matched int
+	Recent  int
+	Old     int
+	// Deleted and Failed are the number of messages whose deletion succeeded or failed.
+	Deleted int
+	Failed  int
+}
+
+// cleanBotMessages deletes the messages sent by the bot in a channel, newest first.
+// Messages younger than bulkDeleteMaxAge are bulk deleted, older ones are deleted one by one
+// while respecting the rate limits. A failed deletion is logged and counted, and does not
+// abort the cleanup.
+//
+// Parameters:
+//   - s: Discord session used to delete the messages
+//   - channelID: The channel to clean
+//   - opts: Limits of the cleanup
+//   - onProgress: Called after each page of messages is processed, may be nil
+//
+// Returns:
+//   - cleanProgress: The final progress of the cleanup
+//   - error: An error if the messages of the channel cannot be fetched, nil otherwise
+func cleanBotMessages(s *discordgo.Session, channelID string, opts cleanOptions, onProgress func(cleanProgress)) (cleanProgress, error) {
+	var progress cleanProgress
+	var lastMessageID string
+	now := time.Now()
+
+	for {
+		messages, err := s.ChannelMessages(channelID, 100, lastMessageID, "", "")
+		if err != nil {
+			return progress, fmt.Errorf("failed to fetch messages: %v", err)
+		}
+
+		if len(messages) == 0 {
+			break
+		}
+
+		// Messages are returned newest first, so the cleanup ends at the first one out of limits
+		reachedLimit := false
+		var recentMessages, oldMessages []string
+		for _, msg := range messages {
+			if opts.MaxAge > 0 && now.Sub(msg.Timestamp) > opts.MaxAge {
+				reachedLimit = true
+				break
+			}
+			lastMessageID = msg.ID
+			progress.Scanned++
+
+			if msg.Author == nil || msg.Author.ID != BotID {
+				continue
+			}
+			if opts.MaxCount > 0 && progress.Matched >= opts.MaxCount {
+				reachedLimit = true
+				break
+			}
+
+			progress.Matched++
+			if now.Sub(msg.Timestamp) < bulkDeleteMaxAge {
+				recentMessages = append(recentMessages, msg.ID)
+			} else {
+				oldMessages = append(oldMessages, msg.ID)
+			}
+		}
+		progress.Recent += len(recentMessages)
+		progress.Old += len(oldMessages)
+
+		if !opts.DryRun {
+			bulkDeleteMessages(s, channelID, recentMessages, &progress)
+			for _, messageID := range oldMessages {
+				deleteMessage(s, channelID, messageID, &progress)
+				time.Sleep(singleDeletePause)
+			}
+		}
+
+		if onProgress != nil {
+			onProgress(progress)
+		}
+
+		if reachedLimit || len(messages) < 100 {
+			break
+		}
+
+		// 為了避免超過 Discord API 的速率限制，在每一頁之間稍作暫停
+		time.Sleep(1 * time.Second)
+	}
+
+	return progress, nil
+}
+
+// bulkDeleteMessages deletes recent messages in batches of bulkDeleteMaxCount,
+// falling back to single deletes for a batch whose bulk delete fails.
+func bulkDeleteMessages(s *discordgo.Session, channelID string, messageIDs []string, progress *cleanProgress) {
+	for len(messageIDs) > 0 {
+		batch := messageIDs[:min(len(messageIDs), bulkDeleteMaxCount)]
+		messageIDs = messageIDs[len(batch):]
+
+		err := s.ChannelMessagesBulkDelete(channelID, batch)
+		if err == nil {
+			progress.Deleted += len(batch)
+			continue
+		}
+
+		slog.Warn("Failed to bulk delete messages, deleting them one by one", "count", len(batch), "channel", channelID, "error", err)
+		for _, messageID := range batch {
+			deleteMessage(s, channelID, messageID, progress)
+			time.Sleep(singleDeletePause)
+		}
+	}
+}
+
+// deleteMessage deletes a single message, waiting and retrying when rate limited.
+func deleteMessage(s *discordgo.Session, channelID, messageID string, progress *cleanProgress) {
+	var err error
+	for attempt := 0; attempt <= maxRateLimitRetries; attempt++ {
+		err = s.ChannelMessageDelete(channelID, messageID, discordgo.WithRetryOnRatelimit(false))
+
+		var rateLimitErr *discordgo.RateLimitError
+		if !errors.As(err, &rateLimitErr) {
+			break
+		}
+		slog.Warn("Rate limited while deleting message", "message", messageID, "retryAfter", rateLimitErr.RetryAfter)
+		time.Sleep(rateLimitErr.RetryAfter)
+	}
+
+	if err != nil {
+		slog.Error("Failed to delete message", "message", messageID, "channel", channelID, "error", err)
+		progress.Failed++
+		return
+	}
+	progress.Deleted++
+}
+
+// formatCleanProgress formats the progress of a cleanup for the response of /clean-gido.
+func formatCleanProgress(lang Language, progress cleanProgress, opts cleanOptions, done bool) string {
+	if opts.DryRun {
+		state := lang.Text("clean.scanning")
+		if done {
+			state = lang.Text("clean.dryRunDone")
+		}
+		return state + "\n" + lang.Text("clean.dryRunSummary", progress.Scanned, progress.Matched, progress.Recent, progress.Old)
+	}
+
+	state := lang.Text("clean.cleaning")
+	if done {
+		state = lang.Text("clean.done")
+	}
+	msg := state + "\n" + lang.Text("clean.summary", progress.Scanned, progress.Deleted, progress.Matched, progress.Recent, progress.Old)
+	if progress.Failed > 0 {
+		msg += "\n" + lang.Text("clean.failed", progress.Failed)
+	}
+	return msg
+}
@@ -0,0 +1,149 @@
+package bot
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// newCleanSession returns a session whose channel "channel" holds messages, newest first,
+// sent by the bot except for those listed in others, and aged by ages.
+func newCleanSession(t *testing.T, ages map[string]time.Duration, others ...string) (*discordgo.Session, *fakeDiscord) {
+	t.Helper()
+
+	previousBotID := BotID
+	BotID = "bot"
+	t.Cleanup(func() { BotID = previousBotID })
+
+	var messages []*discordgo.Message
+	for _, id := range []string{"m5", "m4", "m3", "m2", "m1"} {
+		author := BotID
+		if slices.Contains(others, id) {
+			author = "user"
+		}
+		messages = append(messages, &discordgo.Message{
+			ID:        id,
+			ChannelID: "channel",
+			Author:    &discordgo.User{ID: author},
+			Timestamp: time.Now().Add(-ages[id]),
+		})
+	}
+
+	return newFakeSession(t, func(req fakeRequest) (int, any) {
+		if req.Method == http.MethodGet && req.Path == "/channels/channel/messages" {
+			return 0, messages
+		}
+		return http.StatusNoContent, nil
+	})
+}
+
+// deletedMessages returns the IDs of the messages bulk deleted and deleted one by one.
+func deletedMessages(t *testing.T, fake *fakeDiscord) (bulk, single []string) {
+	t.Helper()
+
+	for _, req := range fake.Requests() {
+		switch {
+		case req.Method == http.MethodPost && req.Path == "/channels/channel/messages/bulk-delete":
+			var body struct {
+				Messages []string `json:"messages"`
+			}
+			if err := json.Unmarshal(req.Body, &body); err != nil {
+				t.Fatalf("invalid bulk delete %s: %v", req.Body, err)
+			}
+			bulk = append(bulk, body.Messages...)
+		case req.Method == http.MethodDelete:
+			single = append(single, req.Path[len("/channels/channel/messages/"):])
+		}
+	}
+	return bulk, single
+}
+
+func TestCleanBotMessagesAgeSplit(t *testing.T) {
+	ages := map[string]time.Duration{
+		"m5": time.Minute,
+		"m4": time.Hour,
+		"m3": 24 * time.Hour,
+		"m2": bulkDeleteMaxAge - time.Minute,
+		"m1": bulkDeleteMaxAge + time.Minute,
+	}
+	s, fake := newCleanSession(t, ages, "m3")
+
+	progress, err := cleanBotMessages(s, "channel", cleanOptions{}, nil)
+	if err != nil {
+		t.Fatalf("cleanup failed: %v", err)
+	}
+
+	want := cleanProgress{Scanned: 5, Matched: 4, Recent: 3, Old: 1, Deleted: 4}
+	if progress != want {
+		t.Fatalf("progress %+v, want %+v", progress, want)
+	}
+	bulk, single := deletedMessages(t, fake)
+	if !slices.Equal(bulk, []string{"m5", "m4", "m2"}) {
+		t.Fatalf("bulk deleted %v, want the bot messages younger than bulkDeleteMaxAge", bulk)
+	}
+	if !slices.Equal(single, []string{"m1"}) {
+		t.Fatalf("deleted %v one by one, want the bot messages older than bulkDeleteMaxAge", single)
+	}
+}
+
+func TestCleanBotMessagesDryRun(t *testing.T) {
+	ages := map[string]time.Duration{
+		"m5": time.Minute,
+		"m4": time.Hour,
+		"m3": 24 * time.Hour,
+		"m2": bulkDeleteMaxAge + time.Minute,
+		"m1": bulkDeleteMaxAge + time.Hour,
+	}
+	s, fake := newCleanSession(t, ages, "m4")
+
+	var reported []cleanProgress
+	progress, err := cleanBotMessages(s, "channel", cleanOptions{DryRun: true}, func(progress cleanProgress) {
+		reported = append(reported, progress)
+	})
+	if err != nil {
+		t.Fatalf("dry run failed: %v", err)
+	}
+
+	want := cleanProgress{Scanned: 5, Matched: 4, Recent: 2, Old: 2}
+	if progress != want {
+		t.Fatalf("progress %+v, want %+v", progress, want)
+	}
+	if len(reported) != 1 || reported[0] != want {
+		t.Fatalf("reported progress %+v, want %+v once", reported, want)
+	}
+	if bulk, single := deletedMessages(t, fake); len(bulk) > 0 || len(single) > 0 {
+		t.Fatalf("dry run deleted %v in bulk and %v one by one", bulk, single)
+	}
+}
+
+func TestCleanBotMessagesLimits(t *testing.T) {
+	ages := map[string]time.Duration{
+		"m5": time.Minute,
+		"m4": time.Hour,
+		"m3": 2 * time.Hour,
+		"m2": 3 * time.Hour,
+		"m1": 4 * time.Hour,
+	}
+
+	s, _ := newCleanSession(t, ages)
+	progress, err := cleanBotMessages(s, "channel", cleanOptions{MaxAge: 90 * time.Minute, DryRun: true}, nil)
+	if err != nil {
+		t.Fatalf("dry run failed: %v", err)
+	}
+	if progress.Scanned != 2 || progress.Matched != 2 {
+		t.Fatalf("progress %+v, want the 2 messages younger than the max age", progress)
+	}
+
+	s, _ = newCleanSession(t, ages)
+	progress, err = cleanBotMessages(s, "channel", cleanOptions{MaxCount: 3, DryRun: true}, nil)
+	if err != nil {
+		t.Fatalf("dry run failed: %v", err)
+	}
+	if progress.Matched != 3 {
+		t.Fatalf("progress %+v, want 3 matched messages", progress)
+	}
+}
@@ -0,0 +1,184 @@
+package bot
+
+import (
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SDxBacon/gido-guardian-bot/gido"
+	"github.com/bwmarrin/discordgo"
+)
+
+// snoozeDuration is how long the Snooze button holds back the threshold alerts.
+const snoozeDuration = 10 * time.Minute
+
+// componentHandler handles a message component or modal submit interaction.
+// args are the parts of the custom ID following the action, see buildCustomID.
+type componentHandler func(s *discordgo.Session, i *discordgo.InteractionCreate, args []string)
+
+// componentHandlers maps the action of a custom ID to its handler, see router.
+var componentHandlers = map[string]componentHandler{
+	"watch-stop":        handleWatchStopComponent,
+	"watch-snooze":      handleWatchSnoozeComponent,
+	"watch-change":      handleWatchChangeComponent,
+	"watch-modal":       handleWatchChangeModalSubmit,
+	"wait-info-refresh": handleWaitInfoRefreshComponent,
+}
+
+// buildCustomID joins an action and its arguments into a component custom ID, e.g. "watch-stop:1234:gido:56".
+func buildCustomID(action string, args ...string) string {
+	return strings.Join(append([]string{action}, args...), ":")
+}
+
+// watchButtons returns the Stop, Snooze and Change ticket number buttons of the watch.
+func watchButtons(lang Language, key WatchKey) []discordgo.MessageComponent {
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Label:    lang.Text("button.stop"),
+					Style:    discordgo.DangerButton,
+					CustomID: buildCustomID("watch-stop", key.String()),
+				},
+				discordgo.Button{
+					Label:    lang.Text("button.snooze"),
+					Style:    discordgo.SecondaryButton,
+					CustomID: buildCustomID("watch-snooze", key.String()),
+				},
+				discordgo.Button{
+					Label:    lang.Text("button.change"),
+					Style:    discordgo.PrimaryButton,
+					CustomID: buildCustomID("watch-change", key.String()),
+				},
+			},
+		},
+	}
+}
+
+// respondEphemeral replies to the interaction with a message only visible to the user.
+func respondEphemeral(s *discordgo.Session, i *discordgo.InteractionCreate, content string) {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		slog.Error("Error responding to interaction", "error", err)
+	}
+}
+
+// getOwnedTicketTracker returns the watch whose key is in args along with its tracker,
+// making sure only the owner of the watch presses its buttons.
+// It responds to the interaction and returns a nil tracker if the user is not the owner
+// or the watch no longer exists, e.g. because its ticket number was changed.
+func getOwnedTicketTracker(s *discordgo.Session, i *discordgo.InteractionCreate, args []string) (WatchKey, *gido.TicketTracker) {
+	key, err := ParseWatchKey(strings.Join(args, ":"))
+	if err != nil || key.UserID != getInteractionUserID(i) {
+		respondEphemeral(s, i, interactionLanguage(i).Text("component.notOwner"))
+		return key, nil
+	}
+
+	tickerTracker := GetTicketTracker(key)
+	if tickerTracker == nil {
+		respondEphemeral(s, i, interactionLanguage(i).Text("component.watchNotFound", key.TicketNumber))
+		return key, nil
+	}
+	return key, tickerTracker
+}
+
+func handleWatchStopComponent(s *discordgo.Session, i *discordgo.InteractionCreate, args []string) {
+	_, tickerTracker := getOwnedTicketTracker(s, i, args)
+	if tickerTracker == nil {
+		return
+	}
+
+	respondEphemeral(s, i, interactionLanguage(i).Text("watch.stopping", tickerTracker.GetTrackingTicketId()))
+	tickerTracker.Stop()
+}
+
+func handleWatchSnoozeComponent(s *discordgo.Session, i *discordgo.InteractionCreate, args []string) {
+	_, tickerTracker := getOwnedTicketTracker(s, i, args)
+	if tickerTracker == nil {
+		return
+	}
+
+	tickerTracker.Snooze(snoozeDuration)
+	until := tickerTracker.GetSnoozedUntil()
+	respondEphemeral(s, i, interactionLanguage(i).Text("component.snoozed", until.Unix()))
+}
+
+// handleWatchChangeComponent opens a modal asking for the new ticket number.
+func handleWatchChangeComponent(s *discordgo.Session, i *discordgo.InteractionCreate, args []string) {
+	key, tickerTracker := getOwnedTicketTracker(s, i, args)
+	if tickerTracker == nil {
+		return
+	}
+	lang := interactionLanguage(i)
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{
+			CustomID: buildCustomID("watch-modal", key.String()),
+			Title:    lang.Text("modal.title"),
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.TextInput{
+							CustomID:  "number",
+							Label:     lang.Text("modal.number"),
+							Style:     discordgo.TextInputShort,
+							Value:     strconv.Itoa(tickerTracker.GetTrackingTicketId()),
+							Required:  true,
+							MaxLength: 6,
+						},
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		slog.Error("Error responding to interaction", "error", err)
+	}
+}
+
+// handleWatchChangeModalSubmit changes the tracked ticket number to the one entered in the modal.
+func handleWatchChangeModalSubmit(s *discordgo.Session, i *discordgo.InteractionCreate, args []string) {
+	key, tickerTracker := getOwnedTicketTracker(s, i, args)
+	if tickerTracker == nil {
+		return
+	}
+	lang := interactionLanguage(i)
+
+	value := getModalTextValue(i, "number")
+	ticketNumber, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || ticketNumber <= 0 {
+		respondEphemeral(s, i, lang.Text("component.invalidTicket", value))
+		return
+	}
+
+	if _, err := ChangeTicketNumber(key, ticketNumber); err != nil {
+		respondEphemeral(s, i, lang.Text("component.changeFailed", lang.ErrorText(err)))
+		return
+	}
+	updateWatchTicketNumber(key, ticketNumber)
+	respondEphemeral(s, i, lang.Text("component.changed", ticketNumber))
+}
+
+// getModalTextValue returns the value of the text input with the given custom ID of a modal submit interaction.
+func getModalTextValue(i *discordgo.InteractionCreate, customID string) string {
+	for _, row := range i.ModalSubmitData().Components {
+		actionsRow, ok := row.(*discordgo.ActionsRow)
+		if !ok {
+			continue
+		}
+		for _, component := range actionsRow.Components {
+			if input, ok := component.(*discordgo.TextInput); ok && input.CustomID == customID {
+				return input.Value
+			}
+		}
+	}
+	return ""
+}
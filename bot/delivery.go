@@ -0,0 +1,114 @@
+package bot
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// DeliveryMode is where a user receives the notifications of their watches.
+type DeliveryMode string
+
+const (
+	// DeliveryChannel posts the notifications in the channel the watch was started from.
+	DeliveryChannel DeliveryMode = "channel"
+	// DeliveryDM sends the notifications as direct messages.
+	DeliveryDM DeliveryMode = "dm"
+	// DeliveryBoth posts the notifications in the channel and sends them as direct messages.
+	DeliveryBoth DeliveryMode = "both"
+)
+
+// PreferencesFile is the JSON file the user preferences are persisted to.
+var PreferencesFile = "preferences.json"
+
+// preferenceStore holds the user preferences, created in Run from PreferencesFile.
+var preferenceStore = NewPreferenceStore(PreferencesFile)
+
+// ParseDeliveryMode validates the name of a delivery mode. An empty name yields DeliveryChannel.
+func ParseDeliveryMode(name string) (DeliveryMode, error) {
+	switch mode := DeliveryMode(name); mode {
+	case "":
+		return DeliveryChannel, nil
+	case DeliveryChannel, DeliveryDM, DeliveryBoth:
+		return mode, nil
+	}
+	return DeliveryChannel, fmt.Errorf("unknown delivery mode %q", name)
+}
+
+// getDeliveryMode returns the delivery mode preferred by the user.
+func getDeliveryMode(userID string) DeliveryMode {
+	mode, err := ParseDeliveryMode(string(preferenceStore.Get(userID).Delivery))
+	if err != nil {
+		return DeliveryChannel
+	}
+	return mode
+}
+
+// notifyUser delivers a notification to the user according to their delivery preference.
+// A direct message that cannot be delivered, e.g. because the user's DMs are closed,
+// falls back to the channel the watch was started from.
+func notifyUser(s *discordgo.Session, userID, channelID, content string) {
+	mode := getDeliveryMode(userID)
+
+	sentToChannel := false
+	if mode == DeliveryChannel || mode == DeliveryBoth {
+		if _, err := s.ChannelMessageSend(channelID, content); err != nil {
+			slog.Error("Failed to notify user in channel", "user", userID, "channel", channelID, "error", err)
+		}
+		sentToChannel = true
+	}
+
+	if mode == DeliveryChannel {
+		return
+	}
+
+	dmChannelID, err := getDMChannelID(s, userID)
+	if err == nil && dmChannelID == channelID && sentToChannel {
+		return // the watch was started from the DM, which already got the message
+	}
+	if err == nil {
+		_, err = s.ChannelMessageSend(dmChannelID, content)
+	}
+	if err != nil && !sentToChannel {
+		slog.Warn("Failed to DM user, falling back to channel", "user", userID, "channel", channelID, "error", err)
+		if _, err := s.ChannelMessageSend(channelID, content); err != nil {
+			slog.Error("Failed to notify user in channel", "user", userID, "channel", channelID, "error", err)
+		}
+	}
+}
+
+// getStatusChannelID returns where the status message of a watch started from channelID lives:
+// the DM channel of the user if they only want direct messages, otherwise channelID.
+func getStatusChannelID(s *discordgo.Session, userID, channelID string) string {
+	if getDeliveryMode(userID) != DeliveryDM {
+		return channelID
+	}
+	dmChannelID, err := getDMChannelID(s, userID)
+	if err != nil {
+		slog.Warn("Failed to open DM, keeping the status in channel", "user", userID, "channel", channelID, "error", err)
+		return channelID
+	}
+	return dmChannelID
+}
+
+// getDMChannelID returns the ID of the direct message channel with the user.
+func getDMChannelID(s *discordgo.Session, userID string) (string, error) {
+	channel, err := s.UserChannelCreate(userID)
+	if err != nil {
+		return "", err
+	}
+	return channel.ID, nil
+}
+
+// getInteractionUserID returns the ID of the user who triggered the interaction.
+// i.Member is only set in guilds, while i.User is only set in direct messages.
+func getInteractionUserID(i *discordgo.InteractionCreate) string {
+	if i.Member != nil && i.Member.User != nil {
+		return i.Member.User.ID
+	}
+	if i.User != nil {
+		return i.User.ID
+	}
+	return ""
+}
@@ -0,0 +1,94 @@
+package bot
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// fakeRequest is a request received by a fakeDiscord.
+type fakeRequest struct {
+	Method string
+	// Path is the path of the endpoint without the API prefix, e.g. "/channels/1/messages".
+	Path string
+	Body []byte
+}
+
+// fakeDiscord stands in for the REST API of Discord, recording the requests of a session and
+// answering them with respond, or with an empty success if respond is nil or returns no status.
+type fakeDiscord struct {
+	mu       sync.Mutex
+	requests []fakeRequest
+	respond  func(req fakeRequest) (status int, body any)
+}
+
+// newFakeSession returns a session whose requests are answered by a fakeDiscord.
+func newFakeSession(t *testing.T, respond func(req fakeRequest) (int, any)) (*discordgo.Session, *fakeDiscord) {
+	t.Helper()
+
+	s, err := discordgo.New("Bot token")
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	fake := &fakeDiscord{respond: respond}
+	s.Client = &http.Client{Transport: fake}
+	s.MaxRestRetries = 0
+	return s, fake
+}
+
+func (f *fakeDiscord) RoundTrip(r *http.Request) (*http.Response, error) {
+	req := fakeRequest{Method: r.Method, Path: strings.TrimPrefix(r.URL.Path, "/api/v"+discordgo.APIVersion)}
+	if r.Body != nil {
+		req.Body, _ = io.ReadAll(r.Body)
+	}
+
+	f.mu.Lock()
+	f.requests = append(f.requests, req)
+	f.mu.Unlock()
+
+	status, body := 0, any(nil)
+	if f.respond != nil {
+		status, body = f.respond(req)
+	}
+	if status == 0 {
+		status = http.StatusOK
+	}
+	data := []byte("{}")
+	if body != nil {
+		data, _ = json.Marshal(body)
+	}
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(bytes.NewReader(data)),
+		Request:    r,
+	}, nil
+}
+
+// Requests returns the requests received so far, in order.
+func (f *fakeDiscord) Requests() []fakeRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]fakeRequest(nil), f.requests...)
+}
+
+// testInteraction returns an interaction of a user of a guild, in English.
+func testInteraction(interactionType discordgo.InteractionType, data discordgo.InteractionData) *discordgo.InteractionCreate {
+	return &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		ID:      "interaction",
+		AppID:   "app",
+		Token:   "token",
+		Type:    interactionType,
+		Data:    data,
+		GuildID: "guild",
+		Member:  &discordgo.Member{User: &discordgo.User{ID: "user"}},
+		Locale:  discordgo.EnglishUS,
+	}}
+}
@@ -0,0 +1,36 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+)
+
+// splitGuildSettings splits per-guild settings formatted as "guildID:setting;guildID:setting",
+// calling fn with the guild ID and the setting of each entry, both trimmed. Empty entries are
+// skipped, so an empty value calls fn for no guild.
+//
+// Parameters:
+//   - value: The settings to split
+//   - format: The expected format of an entry, shown in the errors, e.g. "guildID:language"
+//   - fn: Called for each entry, the error it returns is returned as is
+//
+// Returns:
+//   - error: An error if an entry has no guild ID, or the error of fn, nil otherwise
+func splitGuildSettings(value, format string, fn func(guildID, setting string) error) error {
+	for _, entry := range strings.Split(value, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		guildID, setting, found := strings.Cut(entry, ":")
+		guildID = strings.TrimSpace(guildID)
+		if !found || guildID == "" {
+			return fmt.Errorf("invalid entry %q, expected %s", entry, format)
+		}
+		if err := fn(guildID, strings.TrimSpace(setting)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Language is a language of the bot messages, named after its Discord locale or locale prefix.
+type Language string
+
+const (
+	LanguageZhTW    Language = "zh-TW"
+	LanguageEnglish Language = "en"
+)
+
+// DefaultLanguage is the language of the messages when nothing else chooses one.
+var DefaultLanguage = LanguageZhTW
+
+// catalogs holds the messages of each language, keyed by message ID.
+// To add a language, add its catalog here along with the Discord locales it covers in languageLocales.
+var catalogs = map[Language]map[string]string{
+	LanguageZhTW:    messagesZhTW,
+	LanguageEnglish: messagesEnglish,
+}
+
+// languageLocales are the Discord locales each language is registered for in the command localizations.
+var languageLocales = map[Language][]discordgo.Locale{
+	LanguageZhTW:    {discordgo.ChineseTW},
+	LanguageEnglish: {discordgo.EnglishUS, discordgo.EnglishGB},
+}
+
+// GuildLanguages maps a guild ID to the language forced for the messages of the guild,
+// taking precedence over the locales of the users.
+var GuildLanguages = map[string]Language{}
+
+// Text returns the message with the given ID formatted with args, see fmt.Sprintf.
+// Messages missing from the catalog of the language fall back to DefaultLanguage.
+func (lang Language) Text(id string, args ...any) string {
+	format, exists := catalogs[lang][id]
+	if !exists {
+		format, exists = catalogs[DefaultLanguage][id]
+	}
+	if !exists {
+		return id
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// messageError is an error whose message is a catalog entry, so that it reaches the users in
+// their language, see Language.ErrorText. Error returns the English message, e.g. for the logs.
+type messageError struct {
+	id   string
+	args []any
+}
+
+// newMessageError returns an error with the message of the given ID formatted with args.
+func newMessageError(id string, args ...any) error {
+	return &messageError{id: id, args: args}
+}
+
+func (err *messageError) Error() string {
+	return LanguageEnglish.Text(err.id, err.args...)
+}
+
+// ErrorText returns the message of err in the language if err is or wraps an error created by
+// newMessageError, or err.Error() otherwise.
+func (lang Language) ErrorText(err error) string {
+	var msgErr *messageError
+	if errors.As(err, &msgErr) {
+		return lang.Text(msgErr.id, msgErr.args...)
+	}
+	return err.Error()
+}
+
+// ParseLanguage parses a language name such as "zh-TW" or "en", ignoring case.
+func ParseLanguage(value string) (Language, error) {
+	for lang := range catalogs {
+		if strings.EqualFold(string(lang), value) {
+			return lang, nil
+		}
+	}
+	return "", fmt.Errorf("unknown language %q", value)
+}
+
+// languageFromLocale returns the language of a Discord locale, matching the whole locale
+// first, e.g. "zh-TW", then its prefix, e.g. "en" for "en-GB".
+func languageFromLocale(locale discordgo.Locale) (Language, bool) {
+	if lang, err := ParseLanguage(string(locale)); err == nil {
+		return lang, true
+	}
+	prefix, _, _ := strings.Cut(string(locale), "-")
+	if lang, err := ParseLanguage(prefix); err == nil {
+		return lang, true
+	}
+	return "", false
+}
+
+// ParseGuildLanguages parses the language of each guild, formatted as "guildID:en;guildID:zh-TW".
+// An empty value yields no guild languages.
+func ParseGuildLanguages(value string) (map[string]Language, error) {
+	guildLanguages := map[string]Language{}
+	err := splitGuildSettings(value, "guildID:language", func(guildID, name string) error {
+		lang, err := ParseLanguage(name)
+		if err != nil {
+			return fmt.Errorf("invalid language of guild %s: %v", guildID, err)
+		}
+		guildLanguages[guildID] = lang
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return guildLanguages, nil
+}
+
+// interactionLanguage returns the language of the responses to an interaction: the language
+// of the guild if set, else the locale of the user, else the locale of the guild, else DefaultLanguage.
+func interactionLanguage(i *discordgo.InteractionCreate) Language {
+	if lang, exists := GuildLanguages[i.GuildID]; exists {
+		return lang
+	}
+	if lang, ok := languageFromLocale(i.Locale); ok {
+		return lang
+	}
+	if i.GuildLocale != nil {
+		if lang, ok := languageFromLocale(*i.GuildLocale); ok {
+			return lang
+		}
+	}
+	return DefaultLanguage
+}
+
+// watchLanguage returns the language of the messages of a watch, which outlive the interaction
+// that started it: the language of the guild if set, else the language the watch was started in.
+func watchLanguage(record WatchRecord) Language {
+	if lang, exists := GuildLanguages[record.GuildID]; exists {
+		return lang
+	}
+	if lang, err := ParseLanguage(record.Language); err == nil {
+		return lang
+	}
+	return DefaultLanguage
+}
+
+// localizeCommand fills the name and description localizations of a command, its options
+// and their choices from the catalogs. The command definition itself is the English default,
+// and catalog entries are looked up by the IDs:
+//   - "command.<name>.name" and "command.<name>.description"
+//   - "command.<name>.<option>.description"
+//   - "command.<name>.<option>.<choice value>"
+func localizeCommand(command *discordgo.ApplicationCommand) {
+	names := localizations("command." + command.Name + ".name")
+	descriptions := localizations("command." + command.Name + ".description")
+	command.NameLocalizations = &names
+	command.DescriptionLocalizations = &descriptions
+
+	for idx, option := range command.Options {
+		// options such as storeOption are shared between commands, localize a copy
+		localized := *option
+		localized.DescriptionLocalizations = localizations("command." + command.Name + "." + option.Name + ".description")
+
+		localized.Choices = nil
+		for _, choice := range option.Choices {
+			localizedChoice := *choice
+			localizedChoice.NameLocalizations = localizations(fmt.Sprintf("command.%s.%s.%v", command.Name, option.Name, choice.Value))
+			localized.Choices = append(localized.Choices, &localizedChoice)
+		}
+		command.Options[idx] = &localized
+	}
+}
+
+// localizations returns the catalog entries of a message ID by Discord locale,
+// leaving out the languages missing the message.
+func localizations(id string) map[discordgo.Locale]string {
+	result := map[discordgo.Locale]string{}
+	for lang, locales := range languageLocales {
+		text, exists := catalogs[lang][id]
+		if !exists {
+			continue
+		}
+		for _, locale := range locales {
+			result[locale] = text
+		}
+	}
+	return result
+}
@@ -0,0 +1,49 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestCatalogsHaveSameMessages(t *testing.T) {
+	// the commands are defined in English in commandRoutes, only their localizations are in the catalogs
+	for id := range messagesZhTW {
+		if _, exists := messagesEnglish[id]; !exists && !strings.HasPrefix(id, "command.") {
+			t.Errorf("message %q missing from the English catalog", id)
+		}
+	}
+	for id := range messagesEnglish {
+		if _, exists := messagesZhTW[id]; !exists {
+			t.Errorf("message %q missing from the zh-TW catalog", id)
+		}
+	}
+}
+
+func TestLanguageErrorText(t *testing.T) {
+	err := newMessageError("error.ticketWatched", 42)
+	tests := []struct {
+		name string
+		lang Language
+		err  error
+		want string
+	}{
+		{name: "message error", lang: LanguageZhTW, err: err, want: "Ticket: 42 已經在追蹤中"},
+		{name: "wrapped message error", lang: LanguageZhTW, err: fmt.Errorf("changing the ticket: %w", err), want: "Ticket: 42 已經在追蹤中"},
+		{name: "message error in English", lang: LanguageEnglish, err: err, want: "Ticket 42 is already being watched"},
+		{name: "other error", lang: LanguageZhTW, err: errors.New("connection refused"), want: "connection refused"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.lang.ErrorText(test.err); got != test.want {
+				t.Fatalf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+
+	if got := err.Error(); got != "Ticket 42 is already being watched" {
+		t.Fatalf("Error returned %q, want the English message", got)
+	}
+}
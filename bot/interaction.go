@@ -1,36 +1,49 @@
 package bot
 
 import (
-	"fmt"
-	"log"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/SDxBacon/gido-guardian-bot/gido"
-	"github.com/SDxBacon/go-utils/discord/interaction"
 	"github.com/bwmarrin/discordgo"
 )
 
 // handleWaitInfoInteraction handles the "WaitInfo" interaction command from Discord.
-// It retrieves the current wait info message and responds to the interaction with this message.
-func handleWaitInfoInteraction(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	if i.Type == discordgo.InteractionApplicationCommand && i.ApplicationCommandData().Name != Commands["WaitInfo"] {
+// It retrieves the current wait info and responds to the interaction with an embed showing it,
+// see buildWaitInfoEmbed, along with a Refresh button.
+func handleWaitInfoInteraction(s *discordgo.Session, i *discordgo.InteractionCreate, responder *commandResponder) {
+	lang := interactionLanguage(i)
+
+	// Resolve the store to query, falling back to the default store
+	storeID := getStringOption(i, "store")
+	store, err := Stores.Get(storeID)
+	if err != nil {
+		responder.RespondWithError(lang.Text("error.unknownStore"), err)
+		return
+	}
+	poller, err := Stores.Poller(store.ID)
+	if err != nil {
+		responder.RespondWithError(lang.Text("error.unknownStore"), err)
 		return
 	}
 
-	// Create a new interaction responder
-	responder := interaction.NewInteractionResponder(s, i.Interaction)
+	// Fetching may take longer than Discord waits for a response, e.g. while retrying
+	if err := responder.Defer(); err != nil {
+		slog.Error("Error responding to interaction", "error", err)
+		return
+	}
 
-	// Get the current wait info struct
-	waitInfo, err := gido.GetCurrentWaitInfo()
+	embed, err := waitInfoEmbed(lang, store, poller)
 	if err != nil {
-		responder.RespondWithError("Fail to GET wait info from GIDO", err)
+		responder.RespondWithError(lang.Text("error.fetchWaitInfo"), err)
 		return
 	}
 
-	waitInfoMessage := fmt.Sprintf("當前叫號: %s，總共等待組數: %s", waitInfo.CurrentNumber.String(), waitInfo.TotalWaiting.String())
-
-	err = responder.Respond(waitInfoMessage)
+	err = responder.RespondWithEmbed(embed, waitInfoButtons(lang, store.ID))
 	if err != nil {
-		log.Printf("error: %v", err)
+		slog.Error("Error responding to interaction", "error", err)
 	}
 }
 
@@ -38,117 +51,346 @@ func handleWaitInfoInteraction(s *discordgo.Session, i *discordgo.InteractionCre
 // which allows users to monitor a specified ticket number in a queue system.
 //
 // The function:
-// 1. Validates that the interaction is an application command with the correct name
-// 2. Extracts the user's ticket number from the command options
-// 3. Creates a ticket tracker with appropriate event handlers that:
-//   - Notifies when monitoring starts
-//   - Notifies when monitoring stops
-//   - Reports errors when fetching ticket information
-//   - Handles cases where the ticket number is invalid
-//   - Provides updates on the current ticket number and wait count
-//   - Alerts the user when their ticket number is reached or passed
+// 1. Extracts the user's ticket number, store, alert thresholds, notify policy and polling interval from the command options
+// 2. Starts a persisted watch (see startWatch) that replies to the interaction once monitoring starts
 //
 // Parameters:
 //   - s: Discord session used for responding to the interaction
 //   - i: The interaction data containing command information and user details
-func handleWatchingInteraction(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	if i.Type == discordgo.InteractionApplicationCommand && i.ApplicationCommandData().Name != Commands["Watching"] {
-		return
-	}
-
-	// Get the target number from the interaction
-	options := i.ApplicationCommandData().Options
-	userTicketNumber := int(options[0].IntValue())
-
-	// Create a new interaction responder
-	responder := interaction.NewInteractionResponder(s, i.Interaction)
-
-	// Create a ticket tracker instance
-	ticketTracker, err := CreateUserTicketTracker(i.Member.User.ID, userTicketNumber,
-		// Define the handlers for various events
-		gido.WithTrackerOnStart(func(_ int) {
-			responder.Respond(fmt.Sprintf("開始追蹤 Ticket: %d", userTicketNumber))
-		}),
-		gido.WithTrackerOnStop(func(_ int) {
-			msg := fmt.Sprintf("<@%s> 已停止追蹤 Ticket: %d", i.Member.User.ID, userTicketNumber)
-			s.ChannelMessageSend(i.ChannelID, msg)
-
-			RemoveUserTicketTracker(i.Member.User.ID) // Remove the user ticket tracker when stopped
-		}),
-		gido.WithTrackerOnFetchError(func(err error) {
-			msg := fmt.Sprintf("<@%s> 無法獲取 GIDO 伺服器回應: %v", i.Member.User.ID, err)
-			s.ChannelMessageSend(i.ChannelID, msg)
-		}),
-		gido.WithTrackerOnFetchInvalidTicketNumber(func() {
-			msg := fmt.Sprintf("<@%s> 當前票號: ----，您的票號: %d，無法計算差距", i.Member.User.ID, userTicketNumber)
-			s.ChannelMessageSend(i.ChannelID, msg)
-		}),
-		gido.WithTrackerOnMonitorUpdate(func(currentNumber string, waitCount int) {
-			msg := fmt.Sprintf("<@%s> 當前票號: %s，總共等待組數: %d", i.Member.User.ID, currentNumber, waitCount)
-			s.ChannelMessageSend(i.ChannelID, msg)
-		}),
-		gido.WithTrackerOnTrackComplete(func() {
-			msg := fmt.Sprintf("<@%s> 您的票號: %d 已經到達或已經過號！", i.Member.User.ID, userTicketNumber)
-			s.ChannelMessageSend(i.ChannelID, msg)
-		}))
+func handleWatchingInteraction(s *discordgo.Session, i *discordgo.InteractionCreate, responder *commandResponder) {
+	lang := interactionLanguage(i)
+
+	// Get the target number and the store from the interaction
+	userTicketNumber := int(i.ApplicationCommandData().GetOption("number").IntValue())
+	storeID := getStringOption(i, "store")
+
+	store, err := Stores.Get(storeID)
+	if err != nil {
+		responder.RespondWithError(lang.Text("watch.createFailed"), err)
+		return
+	}
 
+	alertGroups, err := parseThresholds("alert-groups", getStringOption(i, "alert-groups"))
+	if err != nil {
+		responder.RespondWithError(lang.Text("watch.createFailed"), err)
+		return
+	}
+	alertMinutes, err := parseThresholds("alert-minutes", getStringOption(i, "alert-minutes"))
 	if err != nil {
-		responder.Respond(fmt.Sprintf("無法創建 Ticket Tracker: %v", err))
+		responder.RespondWithError(lang.Text("watch.createFailed"), err)
 		return
 	}
 
-	// start the ticket tracker
-	ticketTracker.Start()
+	notifyMode := getStringOption(i, "notify")
+	if _, err := gido.ParseNotifyMode(notifyMode); err != nil {
+		responder.RespondWithError(lang.Text("watch.createFailed"), err)
+		return
+	}
+	notifyMinutes := 0
+	if option := i.ApplicationCommandData().GetOption("notify-minutes"); option != nil {
+		notifyMinutes = int(option.IntValue())
+	}
+	pollSeconds := 0
+	if option := i.ApplicationCommandData().GetOption("poll-seconds"); option != nil {
+		poller, err := Stores.Poller(store.ID)
+		if err != nil {
+			responder.RespondWithError(lang.Text("watch.createFailed"), err)
+			return
+		}
+		pollSeconds = int(option.IntValue())
+		if err := validatePollSeconds(pollSeconds, poller.MinInterval()); err != nil {
+			responder.RespondWithError(lang.Text("watch.createFailed"), err)
+			return
+		}
+	}
+
+	record := WatchRecord{
+		UserID:        getInteractionUserID(i),
+		GuildID:       i.GuildID,
+		ChannelID:     i.ChannelID,
+		TicketNumber:  userTicketNumber,
+		StoreID:       store.ID,
+		StartedAt:     time.Now(),
+		AlertGroups:   alertGroups,
+		AlertMinutes:  alertMinutes,
+		NotifyMode:    notifyMode,
+		NotifyMinutes: notifyMinutes,
+		PollSeconds:   pollSeconds,
+		Language:      string(lang),
+	}
+	err = startWatch(s, record, func() {
+		// Attach the watch buttons to the response
+		err := responder.RespondWithComponents(lang.Text("watch.started", store.Name, userTicketNumber), watchButtons(lang, record.Key()))
+		if err != nil {
+			slog.Error("Error responding to interaction", "error", err)
+		}
+	})
+	if err != nil {
+		responder.RespondWithError(lang.Text("watch.createFailed"), err)
+		return
+	}
 }
 
 // handleStopWatchingInteraction handles the "StopWatching" interaction command from Discord.
-// It checks if the interaction type is an application command and if the command name matches "StopWatching".
-// If the conditions are met, it stops watching the target number by calling gido.StopWatchTicket.
-func handleStopWatchingInteraction(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	if i.Type == discordgo.InteractionApplicationCommand && i.ApplicationCommandData().Name != Commands["StopWatching"] {
+// It stops the watch selected by the optional "ticket" option, or the only watch of the user
+// when the option is not given. The option is either the "storeID:ticketNumber" filled in by
+// the autocomplete, or a ticket number typed by the user.
+func handleStopWatchingInteraction(s *discordgo.Session, i *discordgo.InteractionCreate, responder *commandResponder) {
+	lang := interactionLanguage(i)
+
+	userID := getInteractionUserID(i)
+	keys := GetUserWatchKeys(userID)
+	if len(keys) == 0 {
+		responder.Respond(lang.Text("watch.none"))
 		return
 	}
 
-	responder := interaction.NewInteractionResponder(s, i.Interaction)
+	// Pick the watch to stop
+	var key WatchKey
+	if ticket := getStringOption(i, "ticket"); strings.Contains(ticket, ":") {
+		// "storeID:ticketNumber", as filled in by the autocomplete
+		var err error
+		key, err = ParseWatchKey(userID + ":" + ticket)
+		if err != nil {
+			responder.RespondWithError(lang.Text("component.invalidTicket", ticket), nil)
+			return
+		}
+	} else if ticket != "" {
+		// a bare ticket number typed by the user, matched against the watches of every store
+		ticketNumber, err := strconv.Atoi(strings.TrimPrefix(strings.TrimSpace(ticket), "#"))
+		if err != nil {
+			responder.RespondWithError(lang.Text("component.invalidTicket", ticket), nil)
+			return
+		}
+		var matches []WatchKey
+		for _, userKey := range keys {
+			if userKey.TicketNumber == ticketNumber {
+				matches = append(matches, userKey)
+			}
+		}
+		switch len(matches) {
+		case 0:
+			responder.Respond(lang.Text("watch.notWatching", ticketNumber))
+			return
+		case 1:
+			key = matches[0]
+		default:
+			responder.Respond(lang.Text("watch.severalStores", ticketNumber))
+			return
+		}
+	} else if len(keys) == 1 {
+		key = keys[0]
+	} else {
+		responder.Respond(lang.Text("watch.several"))
+		return
+	}
 
-	// Get the ticker tracker for the user
-	tickerTracker := GetUserTicketTracker(i.Member.User.ID)
+	// Get the ticker tracker of the watch
+	tickerTracker := GetTicketTracker(key)
 	if tickerTracker == nil {
-		responder.Respond("您沒有正在追蹤的 Ticket")
+		responder.Respond(lang.Text("watch.notWatching", key.TicketNumber))
 		return
 	}
 
 	// Stop watching the target number
-	responder.Respond(fmt.Sprintf("正在停止追蹤 Ticket: %d", tickerTracker.GetTrackingTicketId()))
+	responder.Respond(lang.Text("watch.stopping", tickerTracker.GetTrackingTicketId()))
 	tickerTracker.Stop()
 }
 
+// handleMyWatchesInteraction handles the "MyWatches" interaction command from Discord.
+// It lists the active watches of the user who invoked the command.
+func handleMyWatchesInteraction(s *discordgo.Session, i *discordgo.InteractionCreate, responder *commandResponder) {
+	lang := interactionLanguage(i)
+
+	userID := getInteractionUserID(i)
+	var records []WatchRecord
+	for _, record := range watchStore.List() {
+		if record.UserID == userID {
+			records = append(records, record)
+		}
+	}
+
+	list := formatWatchList(lang, records, false)
+	if list == "" {
+		responder.Respond(lang.Text("watch.none"))
+		return
+	}
+	responder.Respond(lang.Text("watches.mine", userID, list))
+}
+
+// handleWatchesInteraction handles the "Watches" interaction command from Discord.
+// It lists the active watches of every user started from the guild the command was invoked in.
+// The list exposes the tickets of every member, so only admins may see it, see isAdmin.
+func handleWatchesInteraction(s *discordgo.Session, i *discordgo.InteractionCreate, responder *commandResponder) {
+	lang := interactionLanguage(i)
+
+	if i.GuildID == "" {
+		responder.Respond(lang.Text("watches.guildOnly"))
+		return
+	}
+	// the router already refuses non-admins, but the handler does not rely on how it is routed
+	if !isAdmin(i) {
+		responder.RespondWithError(lang.Text("error.notAdmin"), nil)
+		return
+	}
+
+	var records []WatchRecord
+	for _, record := range watchStore.List() {
+		if record.GuildID == i.GuildID {
+			records = append(records, record)
+		}
+	}
+
+	list := formatWatchList(lang, records, true)
+	if list == "" {
+		responder.Respond(lang.Text("watches.noneInGuild"))
+		return
+	}
+	// list the owners without pinging every one of them
+	responder.WithoutMentions().Respond(lang.Text("watches.guild", list))
+}
+
 // handleCleanGidoInteraction handles the interaction for cleaning bot messages in a Discord channel.
 // It responds to the interaction with a deferred message indicating that the cleaning process has started.
-// Then, it attempts to delete bot messages in the specified channel and updates the interaction response
-// with the result of the cleaning process.
-func handleCleanGidoInteraction(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	if i.Type == discordgo.InteractionApplicationCommand && i.ApplicationCommandData().Name != Commands["CleanGido"] {
+// Then, it deletes the bot messages in the specified channel, optionally limited by age and count or
+// only counted on a dry run, and edits the interaction response with the progress and the result.
+func handleCleanGidoInteraction(s *discordgo.Session, i *discordgo.InteractionCreate, responder *commandResponder) {
+	lang := interactionLanguage(i)
+
+	opts := cleanOptions{}
+	data := i.ApplicationCommandData()
+	if option := data.GetOption("max-age"); option != nil && option.IntValue() > 0 {
+		opts.MaxAge = time.Duration(option.IntValue()) * 24 * time.Hour
+	}
+	if option := data.GetOption("max-count"); option != nil && option.IntValue() > 0 {
+		opts.MaxCount = int(option.IntValue())
+	}
+	if option := data.GetOption("dry-run"); option != nil {
+		opts.DryRun = option.BoolValue()
+	}
+
+	// Always defer an ephemeral response, which is edited with the progress and is not part of the messages to clean
+	responder.Ephemeral()
+	if err := responder.Defer(); err != nil {
+		slog.Error("Error responding to interaction", "error", err)
 		return
 	}
 
-	// Create a new interaction responder
-	responder := interaction.NewInteractionResponder(s, i.Interaction)
+	editResponse := func(content string) {
+		if err := responder.Respond(content); err != nil {
+			slog.Error("Error editing interaction response", "error", err)
+		}
+	}
+	editResponse(formatCleanProgress(lang, cleanProgress{}, opts, false))
+
+	progress, err := cleanBotMessages(s, i.ChannelID, opts, func(progress cleanProgress) {
+		editResponse(formatCleanProgress(lang, progress, opts, false))
+	})
+	msg := formatCleanProgress(lang, progress, opts, err == nil)
+	if err != nil {
+		msg += "\n" + lang.Text("clean.error", err)
+	}
+	editResponse(msg)
+}
+
+// handleGidoStatsInteraction handles the "GidoStats" interaction command from Discord.
+// It aggregates the recorded queue history of the store by weekday and hour, and responds with
+// the typical queue length and throughput of each hour, followed by the hours with the shortest queue.
+func handleGidoStatsInteraction(s *discordgo.Session, i *discordgo.InteractionCreate, responder *commandResponder) {
+	lang := interactionLanguage(i)
+
+	if recorder == nil {
+		responder.Respond(lang.Text("stats.disabled"))
+		return
+	}
 
-	msg := "正在清理訊息..."
-	err := responder.Respond(msg)
+	store, err := Stores.Get(getStringOption(i, "store"))
 	if err != nil {
-		log.Printf("Error responding to interaction: %v", err)
+		responder.RespondWithError(lang.Text("error.unknownStore"), err)
 		return
 	}
 
-	deletedCount, err := cleanBotMessages(s, i.ChannelID)
+	days := defaultStatsDays
+	if option := i.ApplicationCommandData().GetOption("days"); option != nil && option.IntValue() > 0 {
+		days = int(option.IntValue())
+	}
+
+	stats, err := recorder.Stats(store.ID, time.Now().AddDate(0, 0, -days), store.Hours.Location())
 	if err != nil {
-		msg += fmt.Sprintf("\n❌ 清理訊息時發生錯誤: %v", err)
-		responder.Respond(msg)
+		responder.RespondWithError(lang.Text("error.readHistory"), err)
+		return
+	}
+	if len(stats) == 0 {
+		responder.Respond(lang.Text("stats.empty", store.Name, days))
 		return
 	}
 
-	msg += fmt.Sprintf("\n✅ 成功刪除 %d 條機器人訊息", deletedCount)
-	responder.Respond(msg)
+	err = responder.Respond(formatGidoStats(lang, store, days, stats))
+	if err != nil {
+		slog.Error("Error responding to interaction", "error", err)
+	}
+}
+
+// handleNotifyDeliveryInteraction handles the "NotifyDelivery" interaction command from Discord.
+// It stores where the user wants to receive the notifications of their watches: in the channel,
+// by direct message, or both. The preference also applies to the watches already running.
+func handleNotifyDeliveryInteraction(s *discordgo.Session, i *discordgo.InteractionCreate, responder *commandResponder) {
+	lang := interactionLanguage(i)
+
+	mode, err := ParseDeliveryMode(getStringOption(i, "mode"))
+	if err != nil {
+		responder.RespondWithError(lang.Text("delivery.failed"), err)
+		return
+	}
+
+	userID := getInteractionUserID(i)
+	prefs := preferenceStore.Get(userID)
+	prefs.Delivery = mode
+	if err := preferenceStore.Set(userID, prefs); err != nil {
+		responder.RespondWithError(lang.Text("delivery.failed"), err)
+		return
+	}
+
+	responder.Respond(lang.Text("delivery.updated", userID, lang.Text("delivery."+string(mode))))
+}
+
+// getStringOption returns the value of the named string option of a command interaction,
+// or an empty string if the option was not given.
+func getStringOption(i *discordgo.InteractionCreate, name string) string {
+	option := i.ApplicationCommandData().GetOption(name)
+	if option == nil {
+		return ""
+	}
+	return option.StringValue()
+}
+
+// validatePollSeconds checks the poll-seconds option against the minimum interval of the poller,
+// which would otherwise poll slower than asked without telling the user.
+func validatePollSeconds(pollSeconds int, minInterval time.Duration) error {
+	minSeconds := int((minInterval + time.Second - 1) / time.Second)
+	if minSeconds < 1 {
+		minSeconds = 1
+	}
+	if pollSeconds < minSeconds {
+		return newMessageError("error.pollSeconds", minSeconds)
+	}
+	return nil
+}
+
+// parseThresholds parses the value of the option, a comma separated list of positive numbers such as "10,5,2".
+// An empty value yields no thresholds.
+func parseThresholds(option, value string) ([]int, error) {
+	var thresholds []int
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		threshold, err := strconv.Atoi(field)
+		if err != nil || threshold <= 0 {
+			return nil, newMessageError("error.invalidThreshold", option, field)
+		}
+		thresholds = append(thresholds, threshold)
+	}
+	return thresholds, nil
 }
@@ -0,0 +1,38 @@
+package bot
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestValidatePollSeconds(t *testing.T) {
+	tests := []struct {
+		name        string
+		pollSeconds int
+		minInterval time.Duration
+		wantMin     int
+	}{
+		{name: "above the minimum", pollSeconds: 30, minInterval: 15 * time.Second},
+		{name: "at the minimum", pollSeconds: 15, minInterval: 15 * time.Second},
+		{name: "below the minimum", pollSeconds: 1, minInterval: 15 * time.Second, wantMin: 15},
+		{name: "minimum rounded up", pollSeconds: 1, minInterval: 1500 * time.Millisecond, wantMin: 2},
+		{name: "not positive without minimum", pollSeconds: 0, wantMin: 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := validatePollSeconds(test.pollSeconds, test.minInterval)
+			if test.wantMin == 0 {
+				if err != nil {
+					t.Fatalf("got %v, want no error", err)
+				}
+				return
+			}
+			var msgErr *messageError
+			if !errors.As(err, &msgErr) || msgErr.id != "error.pollSeconds" || msgErr.args[0] != test.wantMin {
+				t.Fatalf("got %v, want the minimum of %d seconds", err, test.wantMin)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package bot
+
+// messagesEnglish is the English message catalog.
+// The commands are defined in English in commandRoutes, so they need no entries here.
+var messagesEnglish = map[string]string{
+	// Errors
+	"error.unexpected":       "Something unexpected went wrong, please try again later",
+	"error.notAdmin":         "You are not allowed to use this command",
+	"error.unknownStore":     "Unknown store",
+	"error.fetchWaitInfo":    "Failed to fetch the wait info from GIDO",
+	"error.readHistory":      "Failed to read the queue history",
+	"error.alreadyWatching":  "<@%s> is already watching %s ticket %d",
+	"error.tooManyWatches":   "<@%s> can watch at most %d tickets at the same time",
+	"error.ticketWatched":    "Ticket %d is already being watched",
+	"error.invalidThreshold": "%s expects positive numbers such as 10,5,2, got %q",
+	"error.pollSeconds":      "poll-seconds must be at least %d",
+
+	// /wait-info
+	"waitInfo.title":           "%s wait info",
+	"waitInfo.currentNumber":   "Now calling",
+	"waitInfo.waiting":         "Groups waiting",
+	"waitInfo.trend":           "%s %+d (vs %d min ago)",
+	"waitInfo.throughput":      "Throughput",
+	"waitInfo.throughputValue": "about %.0f groups per hour",
+	"waitInfo.newTicketETA":    "New ticket",
+	"waitInfo.fetchedAt":       "Data time",
+	"waitInfo.closed":          "🌙 The store is closed now",
+	"waitInfo.nextOpen":        "Opens",
+	"waitInfo.upstreamEmpty":   "⚠️ GIDO answered ----, the queue may not have started yet or the store is closed",
+	"button.refresh":           "Refresh",
+	"eta.soon":                 "expected to be called soon",
+	"eta.minutes":              "expected to be called in about %d minutes",
+
+	// /watching, /stop-watching and the watch lists
+	"watch.createFailed":    "Cannot create the ticket tracker",
+	"watch.started":         "Started watching %s ticket %d",
+	"watch.restored":        "<@%s> The bot restarted, still watching %s ticket %d",
+	"watch.none":            "You are not watching any ticket",
+	"watch.invalidTicket":   "Invalid ticket",
+	"watch.several":         "You are watching several tickets, pick the one to stop with the ticket option",
+	"watch.severalStores":   "You are watching ticket %d at several stores, pick the one to stop with the ticket option",
+	"watch.notWatching":     "You are not watching ticket %d",
+	"watch.stopping":        "Stopping watching ticket %d",
+	"watch.closedStop":      "<@%s> The store closed, stopped watching ticket %d",
+	"watch.expiredStop":     "<@%s> Ticket %d has been watched for too long, stopped watching it",
+	"watch.resetRearm":      "<@%s> %s, still watching ticket %d. If you took a new ticket, use the \"Change ticket\" button",
+	"watch.resetStop":       "<@%s> %s, stopped watching ticket %d. Please check your ticket and watch it again",
+	"reset.numberDrop":      "The numbering restarted (now calling %s → %s)",
+	"reset.newDay":          "A new day started and the numbering restarted (now calling %s → %s)",
+	"watches.mine":          "Tickets watched by <@%s>:\n%s",
+	"watches.guildOnly":     "This command can only be used in a server",
+	"watches.noneInGuild":   "No ticket is being watched in this server",
+	"watches.guild":         "Tickets watched in this server:\n%s",
+	"watchList.firstUpdate": "waiting for the first update",
+	"watchList.groupsAhead": "%d groups ahead",
+	"watchList.separator":   ", ",
+	"watchList.line":        "• %s ticket **%d** — %s, started <t:%d:R>",
+
+	// Watch status message and alerts
+	"status.title":         "%s ticket watch",
+	"status.description":   "<@%s>'s ticket **%d** (%s)",
+	"status.snoozed":       "🔕 Alerts snoozed until <t:%d:t>",
+	"status.groups":        "%d groups",
+	"status.currentNumber": "Now calling",
+	"status.waitCount":     "Groups ahead",
+	"status.eta":           "Estimated wait",
+	"status.updatedAt":     "Last update",
+	"state.watching":       "watching",
+	"state.done":           "reached or passed",
+	"state.stopped":        "stopped",
+	"note.waitingUpdate":   "Waiting for the next update...",
+	"note.fetchError":      "⚠️ No response from the GIDO server, watching resumes once it is back: %v",
+	"note.invalidNumber":   "⚠️ Now calling: ----, cannot count the groups ahead",
+	"note.closed":          "🌙 The store is closed now, it opens <t:%d:f>",
+	"note.closedStop":      "The store closed",
+	"note.expiredStop":     "The watch ran for too long",
+	"note.resetStop":       "The numbering restarted",
+	"note.digest":          "%d groups called since the last notification",
+	"alert.groups":         "<@%[1]s> Reminder: only %[3]d groups left before your ticket %[2]d (now calling: %[4]s)",
+	"alert.minutes":        "<@%s> Reminder: your ticket %d is %s (%d groups ahead)",
+	"alert.complete":       "<@%s> Your ticket %d has been reached or passed!",
+
+	// Upstream outages
+	"outage.down":      "⚠️ The GIDO server of %s is unreachable, watching resumes automatically once it is back",
+	"outage.recovered": "✅ The GIDO server of %s is back, watching resumed",
+
+	// Watch buttons and modal
+	"button.stop":             "Stop",
+	"button.snooze":           "Snooze 10 minutes",
+	"button.change":           "Change ticket",
+	"component.notOwner":      "Only the owner of the watch can use this button",
+	"component.watchNotFound": "Cannot find the watch of ticket %d, please use the buttons of the latest status message",
+	"component.snoozed":       "Alerts snoozed until <t:%d:t>, you will still be notified when your ticket is called",
+	"component.invalidTicket": "Invalid ticket number: %q",
+	"component.changeFailed":  "Cannot change the ticket number: %v",
+	"component.changed":       "Now watching ticket %d",
+	"modal.title":             "Change ticket",
+	"modal.number":            "New ticket number",
+
+	// /clean-gido
+	"clean.scanning":      "🔍 Scanning the messages...",
+	"clean.dryRunDone":    "🔍 Dry run done, no message was deleted",
+	"clean.dryRunSummary": "Scanned %d messages, %d bot messages would be deleted (within 14 days: %d, older: %d)",
+	"clean.cleaning":      "🧹 Cleaning the messages...",
+	"clean.done":          "✅ Cleaning done",
+	"clean.summary":       "Scanned %d messages, deleted %d / %d bot messages (within 14 days: %d, older: %d)",
+	"clean.failed":        "⚠️ Failed to delete %d messages",
+	"clean.error":         "❌ Error while cleaning the messages: %v",
+
+	// /gido-stats
+	"stats.disabled":      "Queue history recording is disabled",
+	"stats.empty":         "No queue history of %s in the last %d days",
+	"stats.title":         "**%s** queue stats of the last %d days (hour: average groups waiting/tickets called per hour)",
+	"stats.bestHour":      "%s %02d:00 (%.1f groups on average)",
+	"stats.bestHours":     "Shortest queues: %s",
+	"stats.listSeparator": ", ",
+	"weekday.0":           "Sun",
+	"weekday.1":           "Mon",
+	"weekday.2":           "Tue",
+	"weekday.3":           "Wed",
+	"weekday.4":           "Thu",
+	"weekday.5":           "Fri",
+	"weekday.6":           "Sat",
+
+	// /notify-delivery
+	"delivery.failed":  "Cannot update the notification delivery",
+	"delivery.updated": "Notifications of <@%s>'s watches will now be delivered %s",
+	"delivery.channel": "in the channel",
+	"delivery.dm":      "by direct message",
+	"delivery.both":    "in the channel and by direct message",
+}
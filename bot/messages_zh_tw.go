@@ -0,0 +1,169 @@
+package bot
+
+// messagesZhTW is the Traditional Chinese message catalog, the default language of the bot.
+var messagesZhTW = map[string]string{
+	// Commands
+	"command.wait-info.name":                      "叫號資訊",
+	"command.wait-info.description":               "查詢吉哆的叫號資訊",
+	"command.wait-info.store.description":         "要查詢的店家，預設為第一間設定的店家",
+	"command.watching.name":                       "追蹤票號",
+	"command.watching.description":                "開始追蹤指定的票號",
+	"command.watching.number.description":         "要追蹤的票號",
+	"command.watching.store.description":          "要追蹤的店家，預設為第一間設定的店家",
+	"command.watching.alert-groups.description":   "剩下這些組數時提醒我，例如 10,5,2",
+	"command.watching.alert-minutes.description":  "預計等待時間降到這些分鐘時提醒我，例如 30,15",
+	"command.watching.notify.description":         "何時更新追蹤進度，預設為票號變動時",
+	"command.watching.notify.change":              "票號變動時",
+	"command.watching.notify.every":               "每 N 分鐘",
+	"command.watching.notify.digest":              "每 N 分鐘彙整",
+	"command.watching.notify-minutes.description": "每 N 分鐘及彙整模式的 N 分鐘，預設為 10",
+	"command.watching.poll-seconds.description":   "每幾秒查詢一次叫號，預設為票號越接近查詢越頻繁",
+	"command.stop-watching.name":                  "停止追蹤",
+	"command.stop-watching.description":           "停止追蹤票號",
+	"command.stop-watching.ticket.description":    "要停止的追蹤，追蹤多個票號時必填",
+	"command.my-watches.name":                     "我的追蹤",
+	"command.my-watches.description":              "列出您正在追蹤的票號",
+	"command.watches.name":                        "伺服器追蹤",
+	"command.watches.description":                 "列出這個伺服器正在追蹤的所有票號",
+	"command.clean-gido.name":                     "清理訊息",
+	"command.clean-gido.description":              "刪除機器人在這個頻道發送的所有訊息",
+	"command.clean-gido.max-age.description":      "只刪除最近這些天內發送的訊息",
+	"command.clean-gido.max-count.description":    "最多刪除這麼多條訊息，從最新的開始",
+	"command.clean-gido.dry-run.description":      "只計算會被刪除的訊息數量",
+	"command.gido-stats.name":                     "排隊統計",
+	"command.gido-stats.description":              "依星期及時段顯示平常的排隊組數及叫號速度",
+	"command.gido-stats.store.description":        "要統計的店家，預設為第一間設定的店家",
+	"command.gido-stats.days.description":         "要統計最近幾天的紀錄，預設為 28",
+	"command.notify-delivery.name":                "通知方式",
+	"command.notify-delivery.description":         "選擇追蹤通知的送達方式",
+	"command.notify-delivery.mode.description":    "通知的送達方式",
+	"command.notify-delivery.mode.channel":        "頻道訊息",
+	"command.notify-delivery.mode.dm":             "私訊",
+	"command.notify-delivery.mode.both":           "頻道訊息及私訊",
+
+	// Errors
+	"error.unexpected":       "處理指令時發生未預期的錯誤，請稍後再試",
+	"error.notAdmin":         "您沒有使用這個指令的權限",
+	"error.unknownStore":     "找不到店家",
+	"error.fetchWaitInfo":    "無法從 GIDO 獲取叫號資訊",
+	"error.readHistory":      "無法讀取排隊紀錄",
+	"error.alreadyWatching":  "<@%s> 已經在追蹤 %s Ticket: %d",
+	"error.tooManyWatches":   "<@%s> 最多只能同時追蹤 %d 個 Ticket",
+	"error.ticketWatched":    "Ticket: %d 已經在追蹤中",
+	"error.invalidThreshold": "%s 需要正整數，例如 10,5,2，收到 %q",
+	"error.pollSeconds":      "poll-seconds 至少需要 %d",
+
+	// /wait-info
+	"waitInfo.title":           "%s 叫號資訊",
+	"waitInfo.currentNumber":   "當前叫號",
+	"waitInfo.waiting":         "等待組數",
+	"waitInfo.trend":           "%s %+d（%d 分鐘前）",
+	"waitInfo.throughput":      "叫號速度",
+	"waitInfo.throughputValue": "每小時約 %.0f 組",
+	"waitInfo.newTicketETA":    "現在取號",
+	"waitInfo.fetchedAt":       "資料時間",
+	"waitInfo.closed":          "🌙 目前非營業時間",
+	"waitInfo.nextOpen":        "下次營業時間",
+	"waitInfo.upstreamEmpty":   "⚠️ GIDO 回傳 ----，可能尚未開始叫號或已經打烊",
+	"button.refresh":           "重新整理",
+	"eta.soon":                 "預計即將叫到",
+	"eta.minutes":              "預計約 %d 分鐘後叫到",
+
+	// /watching, /stop-watching and the watch lists
+	"watch.createFailed":    "無法創建 Ticket Tracker",
+	"watch.started":         "開始追蹤 %s Ticket: %d",
+	"watch.restored":        "<@%s> 機器人已重新啟動，繼續追蹤 %s Ticket: %d",
+	"watch.none":            "您沒有正在追蹤的 Ticket",
+	"watch.invalidTicket":   "無效的 Ticket",
+	"watch.several":         "您正在追蹤多個 Ticket，請使用 ticket 選項指定要停止的 Ticket",
+	"watch.severalStores":   "您在多間店家追蹤 Ticket: %d，請使用 ticket 選項指定要停止的 Ticket",
+	"watch.notWatching":     "您沒有正在追蹤 Ticket: %d",
+	"watch.stopping":        "正在停止追蹤 Ticket: %d",
+	"watch.closedStop":      "<@%s> 營業時間已結束，已停止追蹤 Ticket: %d",
+	"watch.expiredStop":     "<@%s> Ticket: %d 已超過追蹤時間上限，已自動停止追蹤",
+	"watch.resetRearm":      "<@%s> %s，繼續追蹤 Ticket: %d，如果您重新取號了，請使用「更改票號」按鈕",
+	"watch.resetStop":       "<@%s> %s，已停止追蹤 Ticket: %d，請確認票號後重新追蹤",
+	"reset.numberDrop":      "叫號已重新開始（當前票號 %s → %s）",
+	"reset.newDay":          "已經換日，叫號已重新開始（當前票號 %s → %s）",
+	"watches.mine":          "<@%s> 正在追蹤的 Ticket:\n%s",
+	"watches.guildOnly":     "這個指令只能在伺服器中使用",
+	"watches.noneInGuild":   "這個伺服器沒有正在追蹤的 Ticket",
+	"watches.guild":         "這個伺服器正在追蹤的 Ticket:\n%s",
+	"watchList.firstUpdate": "等待第一次更新",
+	"watchList.groupsAhead": "前方 %d 組",
+	"watchList.separator":   "，",
+	"watchList.line":        "• %s Ticket: **%d** — %s，<t:%d:R> 開始追蹤",
+
+	// Watch status message and alerts
+	"status.title":         "%s Ticket 追蹤",
+	"status.description":   "<@%s> 的 Ticket: **%d**（%s）",
+	"status.snoozed":       "🔕 已暫停提醒至 <t:%d:t>",
+	"status.groups":        "%d 組",
+	"status.currentNumber": "當前票號",
+	"status.waitCount":     "前方組數",
+	"status.eta":           "預計時間",
+	"status.updatedAt":     "最後更新",
+	"state.watching":       "追蹤中",
+	"state.done":           "已經到達或已經過號",
+	"state.stopped":        "已停止追蹤",
+	"note.waitingUpdate":   "等待下一次更新...",
+	"note.fetchError":      "⚠️ 無法獲取 GIDO 伺服器回應，恢復連線後會繼續追蹤: %v",
+	"note.invalidNumber":   "⚠️ 當前票號: ----，無法計算差距",
+	"note.closed":          "🌙 目前非營業時間，將於 <t:%d:f> 開始營業",
+	"note.closedStop":      "營業時間已結束",
+	"note.expiredStop":     "已超過追蹤時間上限",
+	"note.resetStop":       "叫號已重新開始",
+	"note.digest":          "上次通知後已叫了 %d 組",
+	"alert.groups":         "<@%s> 提醒: 您的票號 %d 前面只剩 %d 組（當前票號: %s）",
+	"alert.minutes":        "<@%s> 提醒: 您的票號 %d %s（前面還有 %d 組）",
+	"alert.complete":       "<@%s> 您的票號: %d 已經到達或已經過號！",
+
+	// Upstream outages
+	"outage.down":      "⚠️ 無法連線到 %s 的 GIDO 伺服器，恢復連線後會自動繼續追蹤",
+	"outage.recovered": "✅ %s 的 GIDO 伺服器已恢復連線，繼續追蹤中",
+
+	// Watch buttons and modal
+	"button.stop":             "停止追蹤",
+	"button.snooze":           "暫停提醒 10 分鐘",
+	"button.change":           "更改票號",
+	"component.notOwner":      "只有追蹤的擁有者可以使用這個按鈕",
+	"component.watchNotFound": "找不到 Ticket: %d 的追蹤，請使用最新狀態訊息上的按鈕",
+	"component.snoozed":       "已暫停提醒至 <t:%d:t>，叫到您的票號時仍會通知您",
+	"component.invalidTicket": "無效的票號: %q",
+	"component.changeFailed":  "無法更改票號: %v",
+	"component.changed":       "已將追蹤的票號更改為: %d",
+	"modal.title":             "更改票號",
+	"modal.number":            "新的票號",
+
+	// /clean-gido
+	"clean.scanning":      "🔍 正在掃描訊息...",
+	"clean.dryRunDone":    "🔍 試執行完成，未刪除任何訊息",
+	"clean.dryRunSummary": "已掃描 %d 條訊息，將會刪除 %d 條機器人訊息（14 天內: %d 條，超過 14 天: %d 條）",
+	"clean.cleaning":      "🧹 正在清理訊息...",
+	"clean.done":          "✅ 清理完成",
+	"clean.summary":       "已掃描 %d 條訊息，已刪除 %d / %d 條機器人訊息（14 天內: %d 條，超過 14 天: %d 條）",
+	"clean.failed":        "⚠️ %d 條訊息刪除失敗",
+	"clean.error":         "❌ 清理訊息時發生錯誤: %v",
+
+	// /gido-stats
+	"stats.disabled":      "未啟用排隊紀錄功能",
+	"stats.empty":         "%s 近 %d 天沒有排隊紀錄",
+	"stats.title":         "**%s** 近 %d 天排隊統計（時: 平均等待組數/每小時叫號數）",
+	"stats.bestHour":      "%s %02d 時（平均 %.1f 組）",
+	"stats.bestHours":     "最短排隊時段: %s",
+	"stats.listSeparator": "、",
+	"weekday.0":           "週日",
+	"weekday.1":           "週一",
+	"weekday.2":           "週二",
+	"weekday.3":           "週三",
+	"weekday.4":           "週四",
+	"weekday.5":           "週五",
+	"weekday.6":           "週六",
+
+	// /notify-delivery
+	"delivery.failed":  "無法更新通知方式",
+	"delivery.updated": "<@%s> 之後的追蹤通知將以%s送達",
+	"delivery.channel": "頻道訊息",
+	"delivery.dm":      "私訊",
+	"delivery.both":    "頻道訊息及私訊",
+}
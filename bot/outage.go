@@ -0,0 +1,60 @@
+package bot
+
+import (
+	"log/slog"
+
+	"github.com/SDxBacon/gido-guardian-bot/gido"
+	"github.com/bwmarrin/discordgo"
+)
+
+// watchOutages posts a single message when the upstream of a store goes down and another one
+// when it recovers, in every channel showing the status of a watch of the store.
+//
+// Returns:
+//   - func(): A function to stop watching the outages.
+func watchOutages(s *discordgo.Session) func() {
+	var removers []func()
+	for _, store := range Stores.List() {
+		poller, err := Stores.Poller(store.ID)
+		if err != nil {
+			continue
+		}
+		removers = append(removers, poller.OnHealthChange(func(healthy bool, _ error) {
+			notifyOutage(s, store, healthy)
+		}))
+	}
+
+	return func() {
+		for _, remove := range removers {
+			remove()
+		}
+	}
+}
+
+// notifyOutage tells the channels of the watches of the store that its upstream is down,
+// or that it recovered, in the language of the first watch found in each channel.
+func notifyOutage(s *discordgo.Session, store gido.Store, healthy bool) {
+	channels := map[string]Language{}
+	for _, record := range watchStore.List() {
+		if record.StoreID != store.ID {
+			continue
+		}
+		channelID := record.StatusChannelID
+		if channelID == "" {
+			channelID = record.ChannelID
+		}
+		if _, exists := channels[channelID]; !exists {
+			channels[channelID] = watchLanguage(record)
+		}
+	}
+
+	for channelID, lang := range channels {
+		content := lang.Text("outage.down", store.Name)
+		if healthy {
+			content = lang.Text("outage.recovered", store.Name)
+		}
+		if _, err := s.ChannelMessageSend(channelID, content); err != nil {
+			slog.Error("Failed to notify the outage", "store", store.ID, "channel", channelID, "error", err)
+		}
+	}
+}
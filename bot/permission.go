@@ -0,0 +1,67 @@
+package bot
+
+import (
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// adminPermissions are the permissions allowing a member to run the admin commands.
+// They are also the default member permissions of the admin commands, hiding them from
+// the other members unless a server admin overrides it in the integration settings.
+var adminPermissions int64 = discordgo.PermissionManageMessages
+
+// AdminRoles maps a guild ID to the IDs of the roles allowed to run the admin commands in the guild,
+// in addition to the members having adminPermissions.
+var AdminRoles = map[string][]string{}
+
+// ParseAdminRoles parses the admin roles of each guild, formatted as
+// "guildID:roleID,roleID;guildID:roleID". An empty value yields no admin roles.
+func ParseAdminRoles(value string) (map[string][]string, error) {
+	adminRoles := map[string][]string{}
+	err := splitGuildSettings(value, "guildID:roleID,roleID", func(guildID, roles string) error {
+		for _, roleID := range strings.Split(roles, ",") {
+			if roleID = strings.TrimSpace(roleID); roleID != "" {
+				adminRoles[guildID] = append(adminRoles[guildID], roleID)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return adminRoles, nil
+}
+
+// isAdmin reports whether the user of the interaction may run the admin commands:
+// members with adminPermissions in the channel, or with one of the admin roles of the guild.
+// Outside of a guild, e.g. in DMs, there is nobody else to protect and the user is always allowed.
+func isAdmin(i *discordgo.InteractionCreate) bool {
+	if i.Member == nil {
+		return true
+	}
+	if i.Member.Permissions&(adminPermissions|discordgo.PermissionAdministrator) != 0 {
+		return true
+	}
+	for _, roleID := range AdminRoles[i.GuildID] {
+		for _, memberRoleID := range i.Member.Roles {
+			if memberRoleID == roleID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkAdmin makes sure the user of the interaction may run the admin commands,
+// refusing the interaction with an ephemeral message if not.
+//
+// Returns:
+//   - bool: true if the user is allowed, false if the interaction was refused
+func checkAdmin(s *discordgo.Session, i *discordgo.InteractionCreate) bool {
+	if isAdmin(i) {
+		return true
+	}
+	respondEphemeral(s, i, interactionLanguage(i).Text("error.notAdmin"))
+	return false
+}
@@ -0,0 +1,78 @@
+package bot
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestParseAdminRoles(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    map[string][]string
+		wantErr bool
+	}{
+		{name: "empty", value: "", want: map[string][]string{}},
+		{name: "one guild", value: "guild:admins,mods", want: map[string][]string{"guild": {"admins", "mods"}}},
+		{
+			name:  "several guilds with spaces",
+			value: " guild : admins , mods ; other:staff;",
+			want:  map[string][]string{"guild": {"admins", "mods"}, "other": {"staff"}},
+		},
+		{name: "empty roles", value: "guild:, ,", want: map[string][]string{}},
+		{name: "missing guild separator", value: "guild", wantErr: true},
+		{name: "missing guild id", value: ":admins", wantErr: true},
+		{name: "malformed entry after a valid one", value: "guild:admins;mods", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			adminRoles, err := ParseAdminRoles(test.value)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("error %v, want error %v", err, test.wantErr)
+			}
+			if !test.wantErr && !reflect.DeepEqual(adminRoles, test.want) {
+				t.Fatalf("admin roles %v, want %v", adminRoles, test.want)
+			}
+		})
+	}
+}
+
+func TestIsAdmin(t *testing.T) {
+	previous := AdminRoles
+	AdminRoles = map[string][]string{"guild": {"admins"}, "other": {"mods"}}
+	t.Cleanup(func() { AdminRoles = previous })
+
+	member := func(permissions int64, roles ...string) *discordgo.Member {
+		return &discordgo.Member{User: &discordgo.User{ID: "user"}, Permissions: permissions, Roles: roles}
+	}
+
+	tests := []struct {
+		name   string
+		member *discordgo.Member
+		want   bool
+	}{
+		{name: "direct message", member: nil, want: true},
+		{name: "no permission nor role", member: member(discordgo.PermissionSendMessages, "members"), want: false},
+		{name: "admin permission", member: member(adminPermissions), want: true},
+		{name: "administrator", member: member(discordgo.PermissionAdministrator), want: true},
+		{name: "admin role", member: member(0, "members", "admins"), want: true},
+		{name: "admin role of another guild", member: member(0, "mods"), want: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			i := testInteraction(discordgo.InteractionApplicationCommand, discordgo.ApplicationCommandInteractionData{Name: "clean-gido"})
+			i.Member = test.member
+			if test.member == nil {
+				i.GuildID, i.User = "", &discordgo.User{ID: "user"}
+			}
+
+			if got := isAdmin(i); got != test.want {
+				t.Fatalf("admin %v, want %v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package bot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// UserPreferences are the per-user settings of the bot.
+type UserPreferences struct {
+	// Delivery is where the watch notifications are delivered, DeliveryChannel if empty.
+	Delivery DeliveryMode `json:"delivery,omitempty"`
+}
+
+// PreferenceStore persists the user preferences to a JSON file.
+type PreferenceStore struct {
+	mu    sync.Mutex
+	path  string
+	prefs map[string]UserPreferences
+}
+
+// NewPreferenceStore creates a PreferenceStore backed by the JSON file at path.
+// The file is created on the first change if it does not exist.
+func NewPreferenceStore(path string) *PreferenceStore {
+	return &PreferenceStore{
+		path:  path,
+		prefs: map[string]UserPreferences{},
+	}
+}
+
+// Load reads the persisted preferences from disk. A missing file is treated as an empty store.
+func (ps *PreferenceStore) Load() error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	data, err := os.ReadFile(ps.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read preferences file: %v", err)
+	}
+
+	prefs := map[string]UserPreferences{}
+	if err := json.Unmarshal(data, &prefs); err != nil {
+		return fmt.Errorf("failed to parse preferences file: %v", err)
+	}
+	ps.prefs = prefs
+	return nil
+}
+
+// Get returns the preferences of the user, or the zero value if the user has none.
+func (ps *PreferenceStore) Get(userID string) UserPreferences {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	return ps.prefs[userID]
+}
+
+// Set replaces the preferences of the user and writes the store to disk.
+func (ps *PreferenceStore) Set(userID string, prefs UserPreferences) error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	ps.prefs[userID] = prefs
+
+	data, err := json.MarshalIndent(ps.prefs, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode preferences: %v", err)
+	}
+	if err := writeFileAtomic(ps.path, data); err != nil {
+		return fmt.Errorf("failed to write preferences file: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+package bot
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// EphemeralResponses maps a guild ID to the commands whose responses are ephemeral or not
+// in the guild, overriding the default of each command in commandRoutes.
+var EphemeralResponses = map[string]map[string]bool{}
+
+// ParseEphemeralResponses parses the ephemeral response overrides of each guild, formatted as
+// "guildID:command=true,command=false;guildID:command=true". An empty value yields no overrides.
+func ParseEphemeralResponses(value string) (map[string]map[string]bool, error) {
+	overrides := map[string]map[string]bool{}
+	err := splitGuildSettings(value, "guildID:command=true", func(guildID, commands string) error {
+		if overrides[guildID] == nil {
+			overrides[guildID] = map[string]bool{}
+		}
+		for _, command := range strings.Split(commands, ",") {
+			if command = strings.TrimSpace(command); command == "" {
+				continue
+			}
+			name, value, _ := strings.Cut(command, "=")
+			ephemeral, err := strconv.ParseBool(strings.TrimSpace(value))
+			if err != nil {
+				return fmt.Errorf("invalid ephemeral response %q of guild %s: %v", command, guildID, err)
+			}
+			overrides[guildID][strings.TrimSpace(name)] = ephemeral
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return overrides, nil
+}
+
+// isEphemeralResponse reports whether the responses to the command are ephemeral in the guild,
+// falling back to the default of the command when the guild does not override it.
+func isEphemeralResponse(guildID, command string, defaultEphemeral bool) bool {
+	if ephemeral, exists := EphemeralResponses[guildID][command]; exists {
+		return ephemeral
+	}
+	return defaultEphemeral
+}
+
+// commandResponder manages the response to a slash command. The first response is sent,
+// ephemeral or not as configured for the command, and edited by the following ones.
+// Error responses are always ephemeral.
+type commandResponder struct {
+	mu              sync.Mutex
+	s               *discordgo.Session
+	i               *discordgo.InteractionCreate
+	ephemeral       bool
+	allowedMentions *discordgo.MessageAllowedMentions
+	hasResponded    bool
+	// deferred is whether the response is still the placeholder sent by Defer.
+	deferred bool
+}
+
+func newCommandResponder(s *discordgo.Session, i *discordgo.InteractionCreate, ephemeral bool) *commandResponder {
+	return &commandResponder{
+		s:         s,
+		i:         i,
+		ephemeral: ephemeral,
+	}
+}
+
+// WithoutMentions makes the responses list the mentioned users without pinging them.
+func (r *commandResponder) WithoutMentions() *commandResponder {
+	r.allowedMentions = &discordgo.MessageAllowedMentions{}
+	return r
+}
+
+// Ephemeral makes the responses ephemeral regardless of the configuration of the command.
+func (r *commandResponder) Ephemeral() *commandResponder {
+	r.ephemeral = true
+	return r
+}
+
+func (r *commandResponder) flags() discordgo.MessageFlags {
+	if r.ephemeral {
+		return discordgo.MessageFlagsEphemeral
+	}
+	return 0
+}
+
+// Defer acknowledges the command, showing that the bot is thinking until the first Respond.
+func (r *commandResponder) Defer() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.hasResponded {
+		return nil
+	}
+	err := r.s.InteractionRespond(r.i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: r.flags(),
+		},
+	})
+	if err == nil {
+		r.hasResponded, r.deferred = true, true
+	}
+	return err
+}
+
+// Respond sends the response, or edits it if it was already sent.
+func (r *commandResponder) Respond(content string) error {
+	return r.RespondWithComponents(content, nil)
+}
+
+// RespondWithComponents sends the response along with message components, e.g. buttons,
+// or edits it if it was already sent.
+func (r *commandResponder) RespondWithComponents(content string, components []discordgo.MessageComponent) error {
+	return r.respond(content, nil, components)
+}
+
+// RespondWithEmbed sends the response as an embed along with message components,
+// or edits it if it was already sent.
+func (r *commandResponder) RespondWithEmbed(embed *discordgo.MessageEmbed, components []discordgo.MessageComponent) error {
+	return r.respond("", []*discordgo.MessageEmbed{embed}, components)
+}
+
+func (r *commandResponder) respond(content string, embeds []*discordgo.MessageEmbed, components []discordgo.MessageComponent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.hasResponded {
+		edit := &discordgo.WebhookEdit{
+			Content:         &content,
+			AllowedMentions: r.allowedMentions,
+		}
+		if embeds != nil {
+			edit.Embeds = &embeds
+		}
+		if components != nil {
+			edit.Components = &components
+		}
+		_, err := r.s.InteractionResponseEdit(r.i.Interaction, edit)
+		if err == nil {
+			r.deferred = false
+		}
+		return err
+	}
+
+	err := r.s.InteractionRespond(r.i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content:         content,
+			Embeds:          embeds,
+			Components:      components,
+			Flags:           r.flags(),
+			AllowedMentions: r.allowedMentions,
+		},
+	})
+	if err == nil {
+		r.hasResponded = true
+	}
+	return err
+}
+
+// RespondWithError responds with an ephemeral error message, formatted as "msg: err" with err
+// in the language of the interaction, see Language.ErrorText.
+// If a public response was already sent, the error is sent as an ephemeral follow-up message instead,
+// deleting the response if it is only the placeholder sent by Defer.
+func (r *commandResponder) RespondWithError(msg string, err error) error {
+	content := msg
+	if err != nil {
+		content = fmt.Sprintf("%s: %s", msg, interactionLanguage(r.i).ErrorText(err))
+	}
+
+	r.mu.Lock()
+	ephemeral, hasResponded, deferred := r.ephemeral, r.hasResponded, r.deferred
+	if !hasResponded {
+		r.ephemeral = true
+	}
+	r.mu.Unlock()
+
+	if hasResponded && !ephemeral {
+		// a public placeholder left by Defer would keep showing that the bot is thinking
+		if deferred {
+			if err := r.s.InteractionResponseDelete(r.i.Interaction); err != nil {
+				slog.Error("Error deleting interaction response", "error", err)
+			}
+		}
+		_, err := r.s.FollowupMessageCreate(r.i.Interaction, true, &discordgo.WebhookParams{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		})
+		return err
+	}
+	return r.Respond(content)
+}
@@ -0,0 +1,156 @@
+package bot
+
+import (
+	"errors"
+	"log/slog"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// interactionHandler handles an interaction the router dispatched to it.
+type interactionHandler func(s *discordgo.Session, i *discordgo.InteractionCreate)
+
+// commandHandler handles a slash command, responding through the responder configured for the command.
+type commandHandler func(s *discordgo.Session, i *discordgo.InteractionCreate, responder *commandResponder)
+
+// commandRoute is a slash command along with the handler of its invocations.
+// Commands with DefaultMemberPermissions are admin commands, whose invocations
+// are checked by checkAdmin before reaching the handler.
+type commandRoute struct {
+	command *discordgo.ApplicationCommand
+	handler commandHandler
+	// ephemeral is whether the responses are only visible to the user by default,
+	// see EphemeralResponses for the per-guild overrides.
+	ephemeral bool
+}
+
+// autocompleteHandlers maps the name of an autocomplete option to the handler suggesting its values.
+// Options with the same name share their meaning across commands, e.g. "store".
+var autocompleteHandlers = map[string]interactionHandler{
+	"store":  handleStoreAutocomplete,
+	"ticket": handleTicketAutocomplete,
+}
+
+// router dispatches every interaction to its handler by type: slash commands by name,
+// autocompletes by focused option, and message components and modal submits by the
+// action of their custom ID, see componentHandlers.
+// Each handler is guarded against panics and its latency is logged.
+type router struct {
+	commands map[string]commandRoute
+}
+
+func newRouter(routes []commandRoute) *router {
+	r := &router{commands: map[string]commandRoute{}}
+	for _, route := range routes {
+		r.commands[route.command.Name] = route
+	}
+	return r
+}
+
+// handle is the discordgo handler of the interactions.
+func (r *router) handle(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	name, handler, responder := r.resolve(s, i)
+	if handler == nil {
+		slog.Warn("No handler for interaction", "name", name)
+		return
+	}
+
+	start := time.Now()
+	defer func() {
+		if err := recover(); err != nil {
+			slog.Error("Panic while handling interaction", "name", name, "error", err, "stack", string(debug.Stack()))
+			if i.Type != discordgo.InteractionApplicationCommandAutocomplete {
+				respondUnexpected(s, i, responder)
+			}
+			return
+		}
+		slog.Debug("Handled interaction", "name", name, "duration", time.Since(start))
+	}()
+	handler(s, i)
+}
+
+// resolve returns a description of the interaction for the logs along with its handler,
+// or a nil handler if there is none. The handler of a slash command responds through the
+// returned responder, which tells whether it already responded; it is nil for the others.
+func (r *router) resolve(s *discordgo.Session, i *discordgo.InteractionCreate) (string, interactionHandler, *commandResponder) {
+	switch i.Type {
+	case discordgo.InteractionApplicationCommand:
+		data := i.ApplicationCommandData()
+		route, exists := r.commands[data.Name]
+		if !exists {
+			return "command /" + data.Name, nil, nil
+		}
+		responder := newCommandResponder(s, i, isEphemeralResponse(i.GuildID, data.Name, route.ephemeral))
+		return "command /" + data.Name, func(s *discordgo.Session, i *discordgo.InteractionCreate) {
+			if route.command.DefaultMemberPermissions != nil && !checkAdmin(s, i) {
+				return
+			}
+			route.handler(s, i, responder)
+		}, responder
+
+	case discordgo.InteractionApplicationCommandAutocomplete:
+		data := i.ApplicationCommandData()
+		for _, option := range data.Options {
+			if option.Focused {
+				return "autocomplete /" + data.Name + " " + option.Name, autocompleteHandlers[option.Name], nil
+			}
+		}
+		return "autocomplete /" + data.Name, nil, nil
+
+	case discordgo.InteractionMessageComponent:
+		name, handler := resolveComponent("component", i.MessageComponentData().CustomID)
+		return name, handler, nil
+
+	case discordgo.InteractionModalSubmit:
+		name, handler := resolveComponent("modal", i.ModalSubmitData().CustomID)
+		return name, handler, nil
+	}
+	return "interaction type " + i.Type.String(), nil, nil
+}
+
+// respondUnexpected tells the user in an ephemeral message that handling the interaction failed.
+// The handler may have responded before failing, e.g. by deferring a slow command: a command
+// responder then edits or follows up its response, see RespondWithError. The other handlers
+// respond on their own, so a response refused as already sent is replaced by a follow-up message.
+func respondUnexpected(s *discordgo.Session, i *discordgo.InteractionCreate, responder *commandResponder) {
+	content := interactionLanguage(i).Text("error.unexpected")
+	if responder != nil {
+		if err := responder.RespondWithError(content, nil); err != nil {
+			slog.Error("Error responding to interaction", "error", err)
+		}
+		return
+	}
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+	var restErr *discordgo.RESTError
+	if errors.As(err, &restErr) && restErr.Message != nil && restErr.Message.Code == discordgo.ErrCodeInteractionHasAlreadyBeenAcknowledged {
+		_, err = s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		})
+	}
+	if err != nil {
+		slog.Error("Error responding to interaction", "error", err)
+	}
+}
+
+// resolveComponent returns the handler of the action of a custom ID, see buildCustomID.
+func resolveComponent(kind, customID string) (string, interactionHandler) {
+	parts := strings.Split(customID, ":")
+	handler, exists := componentHandlers[parts[0]]
+	if !exists {
+		return kind + " " + customID, nil
+	}
+	return kind + " " + parts[0], func(s *discordgo.Session, i *discordgo.InteractionCreate) {
+		handler(s, i, parts[1:])
+	}
+}
@@ -0,0 +1,161 @@
+package bot
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// responseFlags returns the flags of the interaction response or follow-up message sent in body.
+func responseFlags(t *testing.T, body []byte) discordgo.MessageFlags {
+	t.Helper()
+
+	var payload struct {
+		Flags discordgo.MessageFlags `json:"flags"`
+		Data  struct {
+			Flags discordgo.MessageFlags `json:"flags"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		t.Fatalf("invalid request body %s: %v", body, err)
+	}
+	return payload.Flags | payload.Data.Flags
+}
+
+func TestRouterDispatch(t *testing.T) {
+	var handled []string
+	routes := []commandRoute{
+		{command: &discordgo.ApplicationCommand{Name: "personal"}, ephemeral: true, handler: func(s *discordgo.Session, i *discordgo.InteractionCreate, responder *commandResponder) {
+			handled = append(handled, "personal")
+			responder.Respond("ok")
+		}},
+		{command: &discordgo.ApplicationCommand{Name: "public"}, handler: func(s *discordgo.Session, i *discordgo.InteractionCreate, responder *commandResponder) {
+			handled = append(handled, "public")
+			responder.Respond("ok")
+		}},
+	}
+	componentHandlers["test-action"] = func(s *discordgo.Session, i *discordgo.InteractionCreate, args []string) {
+		handled = append(handled, "component "+strings.Join(args, ","))
+	}
+	defer delete(componentHandlers, "test-action")
+
+	tests := []struct {
+		name          string
+		interaction   *discordgo.InteractionCreate
+		wantHandled   string
+		wantEphemeral bool
+	}{
+		{name: "ephemeral command", interaction: testInteraction(discordgo.InteractionApplicationCommand, discordgo.ApplicationCommandInteractionData{Name: "personal"}), wantHandled: "personal", wantEphemeral: true},
+		{name: "public command", interaction: testInteraction(discordgo.InteractionApplicationCommand, discordgo.ApplicationCommandInteractionData{Name: "public"}), wantHandled: "public"},
+		{name: "unknown command", interaction: testInteraction(discordgo.InteractionApplicationCommand, discordgo.ApplicationCommandInteractionData{Name: "unknown"})},
+		{name: "component", interaction: testInteraction(discordgo.InteractionMessageComponent, discordgo.MessageComponentInteractionData{CustomID: "test-action:1:2"}), wantHandled: "component 1,2"},
+		{name: "unknown component", interaction: testInteraction(discordgo.InteractionMessageComponent, discordgo.MessageComponentInteractionData{CustomID: "unknown:1"})},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handled = nil
+			s, fake := newFakeSession(t, nil)
+			newRouter(routes).handle(s, test.interaction)
+
+			if test.wantHandled == "" {
+				if len(handled) != 0 || len(fake.Requests()) != 0 {
+					t.Fatalf("handled by %v with requests %v, want no handler", handled, fake.Requests())
+				}
+				return
+			}
+			if len(handled) != 1 || handled[0] != test.wantHandled {
+				t.Fatalf("handled by %v, want %s", handled, test.wantHandled)
+			}
+			if test.interaction.Type != discordgo.InteractionApplicationCommand {
+				return
+			}
+			requests := fake.Requests()
+			if len(requests) != 1 {
+				t.Fatalf("got requests %v, want a single response", requests)
+			}
+			if ephemeral := responseFlags(t, requests[0].Body)&discordgo.MessageFlagsEphemeral != 0; ephemeral != test.wantEphemeral {
+				t.Fatalf("ephemeral response %v, want %v", ephemeral, test.wantEphemeral)
+			}
+		})
+	}
+}
+
+func TestRouterPanicRecovery(t *testing.T) {
+	const (
+		callback = "/interactions/interaction/token/callback"
+		followup = "/webhooks/app/token"
+		original = "/webhooks/app/token/messages/@original"
+	)
+	tests := []struct {
+		name         string
+		interaction  *discordgo.InteractionCreate
+		handler      func(s *discordgo.Session, i *discordgo.InteractionCreate, responder *commandResponder)
+		wantRequests []string
+	}{
+		{
+			name:        "before responding",
+			interaction: testInteraction(discordgo.InteractionApplicationCommand, discordgo.ApplicationCommandInteractionData{Name: "test"}),
+			handler: func(s *discordgo.Session, i *discordgo.InteractionCreate, responder *commandResponder) {
+				panic("boom")
+			},
+			wantRequests: []string{"POST " + callback},
+		},
+		{
+			name:        "after deferring",
+			interaction: testInteraction(discordgo.InteractionApplicationCommand, discordgo.ApplicationCommandInteractionData{Name: "test"}),
+			handler: func(s *discordgo.Session, i *discordgo.InteractionCreate, responder *commandResponder) {
+				responder.Defer()
+				panic("boom")
+			},
+			// the public placeholder is replaced by an ephemeral follow-up
+			wantRequests: []string{"POST " + callback, "DELETE " + original, "POST " + followup},
+		},
+		{
+			name:         "component after responding",
+			interaction:  testInteraction(discordgo.InteractionMessageComponent, discordgo.MessageComponentInteractionData{CustomID: "test-action"}),
+			wantRequests: []string{"POST " + callback, "POST " + callback, "POST " + followup},
+		},
+	}
+	componentHandlers["test-action"] = func(s *discordgo.Session, i *discordgo.InteractionCreate, args []string) {
+		respondEphemeral(s, i, "ok")
+		panic("boom")
+	}
+	defer delete(componentHandlers, "test-action")
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Discord accepts a single response per interaction
+			responded := false
+			s, fake := newFakeSession(t, func(req fakeRequest) (int, any) {
+				if req.Path != callback {
+					return 0, nil
+				}
+				if responded {
+					return http.StatusBadRequest, map[string]any{"code": discordgo.ErrCodeInteractionHasAlreadyBeenAcknowledged, "message": "Interaction has already been acknowledged."}
+				}
+				responded = true
+				return http.StatusNoContent, nil
+			})
+			routes := []commandRoute{{command: &discordgo.ApplicationCommand{Name: "test"}, handler: test.handler}}
+			newRouter(routes).handle(s, test.interaction)
+
+			requests := fake.Requests()
+			var got []string
+			for _, req := range requests {
+				got = append(got, req.Method+" "+req.Path)
+			}
+			if strings.Join(got, "\n") != strings.Join(test.wantRequests, "\n") {
+				t.Fatalf("got requests %v, want %v", got, test.wantRequests)
+			}
+			// the user is told about the failure in an ephemeral message
+			last := requests[len(requests)-1]
+			if !strings.Contains(string(last.Body), LanguageEnglish.Text("error.unexpected")) || responseFlags(t, last.Body)&discordgo.MessageFlagsEphemeral == 0 {
+				t.Fatalf("last request %s, want the ephemeral unexpected error", last.Body)
+			}
+		})
+	}
+}
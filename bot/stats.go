@@ -0,0 +1,65 @@
+package bot
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/SDxBacon/gido-guardian-bot/gido"
+)
+
+// defaultStatsDays is how many days of history /gido-stats includes by default.
+const defaultStatsDays = 28
+
+// maxMessageLength is the maximum length of a Discord message, in characters.
+const maxMessageLength = 2000
+
+// formatGidoStats formats the hourly stats of a store as a weekday by hour overview,
+// followed by the three hours with the shortest average queue. When the message would exceed
+// maxMessageLength, the last weekdays of the overview are left out rather than the summary.
+func formatGidoStats(lang Language, store gido.Store, days int, stats []gido.HourlyStats) string {
+	header := lang.Text("stats.title", store.Name, days) + "\n```\n"
+
+	lines := map[time.Weekday][]string{}
+	for _, stat := range stats {
+		throughput := "--"
+		if stat.Throughput >= 0 {
+			throughput = fmt.Sprintf("%.0f", stat.Throughput)
+		}
+		lines[stat.Weekday] = append(lines[stat.Weekday], fmt.Sprintf("%02d: %.0f/%s", stat.Hour, stat.AvgWaiting, throughput))
+	}
+	var rows []string
+	for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+		if len(lines[weekday]) == 0 {
+			continue
+		}
+		rows = append(rows, fmt.Sprintf("%s %s\n", weekdayName(lang, weekday), strings.Join(lines[weekday], "  ")))
+	}
+
+	// list the hours with the shortest queue
+	shortest := append([]gido.HourlyStats(nil), stats...)
+	sort.SliceStable(shortest, func(a, b int) bool {
+		return shortest[a].AvgWaiting < shortest[b].AvgWaiting
+	})
+	var best []string
+	for idx := 0; idx < len(shortest) && idx < 3; idx++ {
+		stat := shortest[idx]
+		best = append(best, lang.Text("stats.bestHour", weekdayName(lang, stat.Weekday), stat.Hour, stat.AvgWaiting))
+	}
+	footer := "```\n" + lang.Text("stats.bestHours", strings.Join(best, lang.Text("stats.listSeparator")))
+
+	// Discord counts the length in characters, not in bytes
+	msg := header + strings.Join(rows, "") + footer
+	for utf8.RuneCountInString(msg) > maxMessageLength && len(rows) > 0 {
+		rows = rows[:len(rows)-1]
+		msg = header + strings.Join(rows, "") + "...\n" + footer
+	}
+	return msg
+}
+
+// weekdayName returns the short name of a weekday, e.g. "週一".
+func weekdayName(lang Language, weekday time.Weekday) string {
+	return lang.Text(fmt.Sprintf("weekday.%d", weekday))
+}
@@ -0,0 +1,61 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/SDxBacon/gido-guardian-bot/gido"
+)
+
+// weekStats returns the stats of every hour of the week with the given average queue.
+func weekStats(avgWaiting, throughput float64) []gido.HourlyStats {
+	var stats []gido.HourlyStats
+	for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+		for hour := 0; hour < 24; hour++ {
+			stats = append(stats, gido.HourlyStats{Weekday: weekday, Hour: hour, Samples: 1, AvgWaiting: avgWaiting, Throughput: throughput})
+		}
+	}
+	return stats
+}
+
+func TestFormatGidoStatsLength(t *testing.T) {
+	store := gido.Store{ID: "gido", Name: "吉哆火鍋百匯"}
+	tests := []struct {
+		name     string
+		stats    []gido.HourlyStats
+		wantRows int
+	}{
+		// more than maxMessageLength bytes, but not characters
+		{name: "fits in characters", stats: weekStats(35, 40), wantRows: 7},
+		{name: "too long", stats: weekStats(150, 120), wantRows: 5},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			msg := formatGidoStats(LanguageZhTW, store, 28, test.stats)
+
+			if length := utf8.RuneCountInString(msg); length > maxMessageLength {
+				t.Fatalf("message of %d characters, want at most %d", length, maxMessageLength)
+			}
+			rows := 0
+			for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+				if strings.Contains(msg, "\n"+weekdayName(LanguageZhTW, weekday)+" ") {
+					rows++
+				}
+			}
+			if rows != test.wantRows {
+				t.Fatalf("got %d weekday rows, want %d:\n%s", rows, test.wantRows, msg)
+			}
+			if !strings.Contains(msg, "```\n最短排隊時段: ") {
+				t.Fatalf("summary missing after the overview:\n%s", msg)
+			}
+		})
+	}
+
+	// the first case is only meaningful if the bytes would exceed the limit
+	if msg := formatGidoStats(LanguageZhTW, store, 28, weekStats(35, 40)); len(msg) <= maxMessageLength {
+		t.Fatalf("message of %d bytes does not exceed the limit in bytes", len(msg))
+	}
+}
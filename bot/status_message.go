@@ -0,0 +1,136 @@
+package bot
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/SDxBacon/gido-guardian-bot/gido"
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	statusColorWatching = 0x3498db
+	statusColorWarning  = 0xf1c40f
+	statusColorDone     = 0x2ecc71
+	statusColorStopped  = 0x95a5a6
+)
+
+// statusMessage is the single message of a watch showing its live status.
+// The message is sent on the first update and edited in place afterwards.
+type statusMessage struct {
+	mu        sync.Mutex
+	s         *discordgo.Session
+	channelID string
+	messageID string
+	// fallbackChannelID is where the message is sent if it cannot be sent to channelID,
+	// e.g. because the user's DMs are closed.
+	fallbackChannelID string
+	// onSent is called with the channel and ID of the message whenever a new message had to be sent.
+	onSent func(channelID, messageID string)
+}
+
+func newStatusMessage(s *discordgo.Session, channelID, messageID, fallbackChannelID string, onSent func(channelID, messageID string)) *statusMessage {
+	return &statusMessage{
+		s:                 s,
+		channelID:         channelID,
+		messageID:         messageID,
+		fallbackChannelID: fallbackChannelID,
+		onSent:            onSent,
+	}
+}
+
+// update shows the embed and components in the status message, sending a new message if there
+// is none yet or if the previous one can no longer be edited, e.g. because it was deleted.
+func (m *statusMessage) update(embed *discordgo.MessageEmbed, components []discordgo.MessageComponent) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if components == nil {
+		components = []discordgo.MessageComponent{}
+	}
+	embeds := []*discordgo.MessageEmbed{embed}
+
+	if m.messageID != "" {
+		_, err := m.s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+			ID:         m.messageID,
+			Channel:    m.channelID,
+			Embeds:     &embeds,
+			Components: &components,
+		})
+		if err == nil {
+			return
+		}
+		slog.Warn("Failed to edit status message, sending a new one", "message", m.messageID, "error", err)
+	}
+
+	send := &discordgo.MessageSend{
+		Embeds:     embeds,
+		Components: components,
+	}
+	msg, err := m.s.ChannelMessageSendComplex(m.channelID, send)
+	if err != nil && m.fallbackChannelID != m.channelID {
+		slog.Warn("Failed to send status message, falling back to another channel", "channel", m.channelID, "fallback", m.fallbackChannelID, "error", err)
+		m.channelID = m.fallbackChannelID
+		msg, err = m.s.ChannelMessageSendComplex(m.channelID, send)
+	}
+	if err != nil {
+		slog.Error("Failed to send status message", "error", err)
+		return
+	}
+	m.messageID = msg.ID
+	m.onSent(m.channelID, msg.ID)
+}
+
+// watchStatus is what the status message of a watch shows.
+type watchStatus struct {
+	lang         Language
+	userID       string
+	storeName    string
+	ticketNumber int
+	// status is the latest tracker status, or nil if no update has been received yet.
+	status *gido.TrackerStatus
+	// state is a short description of the watch state, e.g. "追蹤中".
+	state string
+	// note is an optional detail, e.g. the last fetch error.
+	note  string
+	color int
+	// snoozedUntil is until when the threshold alerts are snoozed.
+	snoozedUntil time.Time
+}
+
+// buildStatusEmbed renders the status message of a watch.
+func buildStatusEmbed(ws watchStatus) *discordgo.MessageEmbed {
+	currentNumber, waitCount, eta := "----", "--", "--"
+	updatedAt := time.Now()
+	if ws.status != nil {
+		currentNumber = ws.status.CurrentNumber.String()
+		waitCount = ws.lang.Text("status.groups", ws.status.WaitCount)
+		if ws.status.HasETA {
+			eta = formatETA(ws.lang, ws.status.ETA)
+		}
+		updatedAt = ws.status.UpdatedAt
+	}
+
+	description := ws.lang.Text("status.description", ws.userID, ws.ticketNumber, ws.state)
+	if ws.note != "" {
+		description += "\n" + ws.note
+	}
+	if ws.snoozedUntil.After(time.Now()) {
+		description += "\n" + ws.lang.Text("status.snoozed", ws.snoozedUntil.Unix())
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       ws.lang.Text("status.title", ws.storeName),
+		Description: description,
+		Color:       ws.color,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: ws.lang.Text("status.currentNumber"), Value: currentNumber, Inline: true},
+			{Name: ws.lang.Text("status.waitCount"), Value: waitCount, Inline: true},
+			{Name: ws.lang.Text("status.eta"), Value: eta, Inline: true},
+			{Name: ws.lang.Text("status.updatedAt"), Value: fmt.Sprintf("<t:%d:R>", updatedAt.Unix()), Inline: false},
+		},
+		Timestamp: updatedAt.Format(time.RFC3339),
+	}
+}
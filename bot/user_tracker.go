@@ -2,64 +2,163 @@ package bot
 
 import (
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 
 	"github.com/SDxBacon/gido-guardian-bot/gido"
 )
 
-var userTicketTrackersMap = map[string]*gido.TicketTracker{}
+// MaxWatchesPerUser is how many tickets a user can watch at the same time.
+var MaxWatchesPerUser = 3
+
+// WatchKey identifies a watch: a user following a ticket number of a store.
+type WatchKey struct {
+	UserID       string
+	StoreID      string
+	TicketNumber int
+}
+
+// String returns the key as "userID:storeID:ticketNumber", the form used in component custom IDs.
+func (key WatchKey) String() string {
+	return fmt.Sprintf("%s:%s:%d", key.UserID, key.StoreID, key.TicketNumber)
+}
+
+// ParseWatchKey parses a key formatted by WatchKey.String.
+func ParseWatchKey(value string) (WatchKey, error) {
+	parts := strings.Split(value, ":")
+	if len(parts) != 3 {
+		return WatchKey{}, fmt.Errorf("invalid watch key %q", value)
+	}
+	ticketNumber, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return WatchKey{}, fmt.Errorf("invalid watch key %q", value)
+	}
+	return WatchKey{UserID: parts[0], StoreID: parts[1], TicketNumber: ticketNumber}, nil
+}
+
+var ticketTrackersMap = map[WatchKey]*gido.TicketTracker{}
 
 var mutex = &sync.Mutex{}
 
-// GetUserTicketTracker retrieves the ticket tracker for a specific user.
-// It safely accesses the shared user ticket trackers map with mutex protection.
+// GetTicketTracker retrieves the ticket tracker of a watch.
+// It safely accesses the shared ticket trackers map with mutex protection.
 //
 // Parameters:
-//   - userID: The unique identifier of the user whose ticket tracker is being retrieved.
+//   - key: The watch whose ticket tracker is being retrieved.
 //
 // Returns:
-//   - *gido.TicketTracker: The user's ticket tracker if found, or nil if no tracker exists for the user.
-func GetUserTicketTracker(userID string) *gido.TicketTracker {
+//   - *gido.TicketTracker: The ticket tracker if found, or nil if the watch does not exist.
+func GetTicketTracker(key WatchKey) *gido.TicketTracker {
 	mutex.Lock()
 	defer mutex.Unlock()
 
-	if tracker, exists := userTicketTrackersMap[userID]; exists {
+	if tracker, exists := ticketTrackersMap[key]; exists {
 		return tracker
 	}
 	return nil
 }
 
-// CreateUserTicketTracker creates a new ticket tracker for a specific user.
-// It takes the Discord user ID, ticket number to track, and optional configuration options.
-// If a tracker already exists for the specified user, it returns an error.
+// GetUserWatchKeys returns the watches of a user, ordered by store and ticket number.
+func GetUserWatchKeys(userID string) []WatchKey {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	var keys []WatchKey
+	for key := range ticketTrackersMap {
+		if key.UserID == userID {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(a, b int) bool {
+		if keys[a].StoreID != keys[b].StoreID {
+			return keys[a].StoreID < keys[b].StoreID
+		}
+		return keys[a].TicketNumber < keys[b].TicketNumber
+	})
+	return keys
+}
+
+// CreateUserTicketTracker creates a new ticket tracker for a watch.
+// It takes the watch to create and optional configuration options; an empty key.StoreID
+// is replaced by the default store.
+// If the watch already exists, the user reached MaxWatchesPerUser, or the store is unknown, it returns an error.
 // The function is thread-safe as it uses a mutex to protect access to the shared tracker map.
 //
 // Parameters:
-//   - userID: The Discord user ID as a string
-//   - ticketNumber: The ticket number to track
+//   - key: The user, store and ticket number to track
 //   - opts: Optional configuration options for the ticket tracker
 //
 // Returns:
 //   - *gido.TicketTracker: The newly created ticket tracker, or nil if an error occurred
-//   - error: An error if the user already has a tracker, nil otherwise
-func CreateUserTicketTracker(userID string, ticketNumber int, opts ...gido.TicketTrackerOption) (*gido.TicketTracker, error) {
+//   - error: An error if the tracker cannot be created, nil otherwise
+func CreateUserTicketTracker(key WatchKey, opts ...gido.TicketTrackerOption) (*gido.TicketTracker, error) {
+	store, err := Stores.Get(key.StoreID)
+	if err != nil {
+		return nil, err
+	}
+	poller, err := Stores.Poller(store.ID)
+	if err != nil {
+		return nil, err
+	}
+	key.StoreID = store.ID
+
 	mutex.Lock()
 	defer mutex.Unlock()
 
-	if tickerTracker, exists := userTicketTrackersMap[userID]; exists {
+	if _, exists := ticketTrackersMap[key]; exists {
+		return nil, newMessageError("error.alreadyWatching", key.UserID, store.Name, key.TicketNumber)
+	}
 
-		return nil, fmt.Errorf("tracker for user <@%s> already exists (tracking: %d)", userID, tickerTracker.GetTrackingTicketId())
+	watchCount := 0
+	for existingKey := range ticketTrackersMap {
+		if existingKey.UserID == key.UserID {
+			watchCount++
+		}
+	}
+	if watchCount >= MaxWatchesPerUser {
+		return nil, newMessageError("error.tooManyWatches", key.UserID, MaxWatchesPerUser)
 	}
 
-	tracker := gido.NewTicketTracker(ticketNumber, opts...)
-	userTicketTrackersMap[userID] = tracker
+	// Let the caller options override the store and its shared poller
+	opts = append([]gido.TicketTrackerOption{gido.WithTrackerStore(store), gido.WithTrackerPoller(poller)}, opts...)
+	tracker := gido.NewTicketTracker(key.TicketNumber, opts...)
+	ticketTrackersMap[key] = tracker
 
 	return tracker, nil
 }
 
-func RemoveUserTicketTracker(userID string) {
+// ChangeTicketNumber moves a watch to another ticket number of the same store,
+// updating the tracked ticket number of its tracker.
+//
+// Returns:
+//   - WatchKey: The new key of the watch
+//   - error: An error if the watch does not exist or the new ticket is already watched, nil otherwise
+func ChangeTicketNumber(key WatchKey, ticketNumber int) (WatchKey, error) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	tracker, exists := ticketTrackersMap[key]
+	if !exists {
+		return key, newMessageError("component.watchNotFound", key.TicketNumber)
+	}
+
+	newKey := key
+	newKey.TicketNumber = ticketNumber
+	if _, exists := ticketTrackersMap[newKey]; exists && newKey != key {
+		return key, newMessageError("error.ticketWatched", ticketNumber)
+	}
+
+	delete(ticketTrackersMap, key)
+	ticketTrackersMap[newKey] = tracker
+	tracker.SetTrackingTicketId(ticketNumber)
+	return newKey, nil
+}
+
+func RemoveTicketTracker(key WatchKey) {
 	mutex.Lock()
 	defer mutex.Unlock()
 
-	delete(userTicketTrackersMap, userID)
+	delete(ticketTrackersMap, key)
 }
@@ -0,0 +1,189 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/SDxBacon/gido-guardian-bot/gido"
+	"github.com/bwmarrin/discordgo"
+)
+
+// waitInfoTrendWindow is how far back /wait-info compares the number of groups waiting.
+const waitInfoTrendWindow = 5 * time.Minute
+
+// fetchWaitInfo fetches the wait info through the poller of a store. The closed and not yet
+// called queues are not errors here, as their wait info is shown with the "----" placeholders.
+func fetchWaitInfo(poller *gido.Poller) (gido.WaitInfo, error) {
+	info, err := poller.Fetch()
+	if errors.Is(err, gido.ErrUpstreamClosed) || errors.Is(err, gido.ErrNoTicketYet) {
+		return info, nil
+	}
+	return info, err
+}
+
+// waitInfoEmbed renders the current wait info of a store, or that the store is closed when
+// outside its opening hours, in which case the upstream is not queried.
+func waitInfoEmbed(lang Language, store gido.Store, poller *gido.Poller) (*discordgo.MessageEmbed, error) {
+	now := time.Now()
+	if !store.Hours.IsOpen(now) {
+		return buildClosedEmbed(lang, store, now), nil
+	}
+
+	// Fetch the current wait info through the shared poller, which also records it for the trend
+	info, err := fetchWaitInfo(poller)
+	if err != nil {
+		return nil, err
+	}
+	return buildWaitInfoEmbed(lang, store, poller, info, time.Now()), nil
+}
+
+// buildClosedEmbed tells that the store is closed at now, along with when it opens next.
+func buildClosedEmbed(lang Language, store gido.Store, now time.Time) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title:       lang.Text("waitInfo.title", store.Name),
+		Description: lang.Text("waitInfo.closed"),
+		Color:       statusColorStopped,
+		Timestamp:   now.Format(time.RFC3339),
+	}
+	if openAt, ok := store.Hours.NextOpen(now); ok {
+		embed.Fields = []*discordgo.MessageEmbedField{
+			{Name: lang.Text("waitInfo.nextOpen"), Value: fmt.Sprintf("<t:%d:F>\n<t:%d:R>", openAt.Unix(), openAt.Unix())},
+		}
+	}
+	return embed
+}
+
+// buildWaitInfoEmbed renders the wait info of a store fetched at fetchedAt, along with the
+// trend of the queue and the service rate observed by the poller of the store.
+func buildWaitInfoEmbed(lang Language, store gido.Store, poller *gido.Poller, info gido.WaitInfo, fetchedAt time.Time) *discordgo.MessageEmbed {
+	waiting := info.TotalWaiting.String()
+	if info.TotalWaiting >= 0 {
+		waiting = lang.Text("status.groups", int(info.TotalWaiting))
+		if trend := formatWaitingTrend(lang, poller, info, fetchedAt); trend != "" {
+			waiting += " " + trend
+		}
+	}
+
+	throughput := "--"
+	if rate, ok := poller.Rate().Rate(); ok {
+		throughput = lang.Text("waitInfo.throughputValue", rate*60)
+	}
+
+	eta := "--"
+	if info.TotalWaiting >= 0 {
+		if wait, ok := poller.Rate().EstimateWait(int(info.TotalWaiting)); ok {
+			eta = formatETA(lang, wait)
+		}
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title: lang.Text("waitInfo.title", store.Name),
+		Color: statusColorWatching,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: lang.Text("waitInfo.currentNumber"), Value: info.CurrentNumber.String(), Inline: true},
+			{Name: lang.Text("waitInfo.waiting"), Value: waiting, Inline: true},
+			{Name: lang.Text("waitInfo.throughput"), Value: throughput, Inline: true},
+			{Name: lang.Text("waitInfo.newTicketETA"), Value: eta, Inline: true},
+			{Name: lang.Text("waitInfo.fetchedAt"), Value: fmt.Sprintf("<t:%d:T>\n<t:%d:R>", fetchedAt.Unix(), fetchedAt.Unix()), Inline: true},
+		},
+		Timestamp: fetchedAt.Format(time.RFC3339),
+	}
+	// the upstream answers "----" when the queue is not running, e.g. before opening
+	if info.CurrentNumber <= 0 || info.TotalWaiting < 0 {
+		embed.Description = lang.Text("waitInfo.upstreamEmpty")
+		embed.Color = statusColorWarning
+	}
+	return embed
+}
+
+// formatWaitingTrend compares the number of groups waiting with the snapshot of the poller
+// from waitInfoTrendWindow ago, e.g. "↑ +3（5 分鐘前）". It returns an empty string when
+// there is nothing to compare with.
+func formatWaitingTrend(lang Language, poller *gido.Poller, info gido.WaitInfo, fetchedAt time.Time) string {
+	previous, ok := poller.SnapshotBefore(fetchedAt.Add(-waitInfoTrendWindow))
+	if !ok || previous.Info.TotalWaiting < 0 {
+		return ""
+	}
+
+	diff := int(info.TotalWaiting - previous.Info.TotalWaiting)
+	arrow := "→"
+	switch {
+	case diff > 0:
+		arrow = "↑"
+	case diff < 0:
+		arrow = "↓"
+	}
+	minutes := int(math.Round(fetchedAt.Sub(previous.At).Minutes()))
+	return lang.Text("waitInfo.trend", arrow, diff, minutes)
+}
+
+// waitInfoButtons returns the Refresh button of the wait info of a store.
+func waitInfoButtons(lang Language, storeID string) []discordgo.MessageComponent {
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Label:    lang.Text("button.refresh"),
+					Style:    discordgo.SecondaryButton,
+					Emoji:    &discordgo.ComponentEmoji{Name: "🔄"},
+					CustomID: buildCustomID("wait-info-refresh", storeID),
+				},
+			},
+		},
+	}
+}
+
+// handleWaitInfoRefreshComponent fetches the wait info of the store again and updates
+// the embed of the message whose Refresh button was pressed.
+func handleWaitInfoRefreshComponent(s *discordgo.Session, i *discordgo.InteractionCreate, args []string) {
+	lang := interactionLanguage(i)
+
+	var storeID string
+	if len(args) > 0 {
+		storeID = args[0]
+	}
+	store, err := Stores.Get(storeID)
+	if err != nil {
+		respondEphemeral(s, i, lang.Text("error.unknownStore")+": "+err.Error())
+		return
+	}
+	poller, err := Stores.Poller(store.ID)
+	if err != nil {
+		respondEphemeral(s, i, lang.Text("error.unknownStore")+": "+err.Error())
+		return
+	}
+
+	// Fetching may take longer than Discord waits for a response, e.g. while retrying,
+	// so acknowledge the press first and edit the message once the wait info is fetched
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredMessageUpdate,
+	})
+	if err != nil {
+		slog.Error("Error responding to interaction", "error", err)
+		return
+	}
+
+	embed, err := waitInfoEmbed(lang, store, poller)
+	if err != nil {
+		_, err = s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: lang.Text("error.fetchWaitInfo") + ": " + err.Error(),
+			Flags:   discordgo.MessageFlagsEphemeral,
+		})
+		if err != nil {
+			slog.Error("Error sending follow-up message", "error", err)
+		}
+		return
+	}
+
+	embeds, components := []*discordgo.MessageEmbed{embed}, waitInfoButtons(lang, store.ID)
+	_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Embeds:     &embeds,
+		Components: &components,
+	})
+	if err != nil {
+		slog.Error("Error editing interaction response", "error", err)
+	}
+}
@@ -0,0 +1,270 @@
+package bot
+
+import (
+	"log"
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/SDxBacon/gido-guardian-bot/gido"
+	"github.com/bwmarrin/discordgo"
+)
+
+// WatchesFile is the JSON file the active watches are persisted to.
+var WatchesFile = "watches.json"
+
+// watchStore persists the active watches, created in Run from WatchesFile.
+var watchStore = NewWatchStore(WatchesFile)
+
+// ResetAction is what a watch does when the numbering of its store is reset.
+var ResetAction = gido.ResetRearm
+
+// AdaptivePolling makes the watches poll at AdaptiveInterval, faster as their ticket gets closer,
+// unless their user asked for an interval with the poll-seconds option of /watching.
+// When disabled, they poll at the interval of the poller of their store.
+var AdaptivePolling = true
+
+// AdaptiveInterval is the adaptive polling of the watches when AdaptivePolling is enabled.
+var AdaptiveInterval = gido.DefaultAdaptiveInterval
+
+// MaxWatchDuration is how long a watch runs at most before it stops automatically; 0 disables the limit.
+var MaxWatchDuration = 6 * time.Hour
+
+// startWatch creates and starts the ticket tracker described by record, and persists it
+// so it can be restored after a restart. The tracker keeps a single status message in
+// record.ChannelID, or the user's DM channel, up to date, editing it in place when:
+//   - Monitoring stops
+//   - Fetching ticket information fails
+//   - The current ticket number is invalid
+//   - The current ticket number and wait count are updated
+//
+// New messages mentioning the user are only sent when an alert threshold is reached, when
+// their ticket number is reached or passed, and when the watch stops automatically at the
+// end of the opening session or after MaxWatchDuration, and when the numbering is reset,
+// delivered according to their preference.
+//
+// Parameters:
+//   - s: Discord session used to post the notifications
+//   - record: The watch to start
+//   - onStart: Called once the tracker has started monitoring
+//
+// Returns:
+//   - error: An error if the tracker cannot be created, nil otherwise
+func startWatch(s *discordgo.Session, record WatchRecord, onStart func()) error {
+	userID := record.UserID
+	lang := watchLanguage(record)
+
+	store, err := Stores.Get(record.StoreID)
+	if err != nil {
+		return err
+	}
+
+	var thresholds []gido.Threshold
+	for _, groups := range record.AlertGroups {
+		thresholds = append(thresholds, gido.Threshold{Kind: gido.ThresholdGroups, Value: groups})
+	}
+	for _, minutes := range record.AlertMinutes {
+		thresholds = append(thresholds, gido.Threshold{Kind: gido.ThresholdMinutes, Value: minutes})
+	}
+
+	notifyMode, err := gido.ParseNotifyMode(record.NotifyMode)
+	if err != nil {
+		return err
+	}
+	notifyPolicy := gido.NotifyPolicy{
+		Mode:     notifyMode,
+		Interval: time.Duration(record.NotifyMinutes) * time.Minute,
+	}
+
+	// The status message shown to the user, remembering its ID so a restored watch keeps editing it
+	status := watchStatus{
+		lang:         lang,
+		userID:       userID,
+		storeName:    store.Name,
+		ticketNumber: record.TicketNumber,
+		state:        lang.Text("state.watching"),
+		color:        statusColorWatching,
+	}
+
+	var ticketTracker *gido.TicketTracker
+	// currentKey returns the key of the watch, which changes with the tracked ticket number
+	currentKey := func() WatchKey {
+		return WatchKey{UserID: userID, StoreID: store.ID, TicketNumber: ticketTracker.GetTrackingTicketId()}
+	}
+	statusChannelID := record.StatusChannelID
+	if statusChannelID == "" {
+		statusChannelID = getStatusChannelID(s, userID, record.ChannelID)
+	}
+	statusMsg := newStatusMessage(s, statusChannelID, record.StatusMessageID, record.ChannelID, func(channelID, messageID string) {
+		err := watchStore.Update(currentKey(), func(record *WatchRecord) {
+			record.StatusChannelID, record.StatusMessageID = channelID, messageID
+		})
+		if err != nil {
+			slog.Error("Failed to persist watch", "watch", currentKey(), "error", err)
+		}
+	})
+
+	running := true
+	showStatus := func(state, note string, color int) {
+		status.state, status.note, status.color = state, note, color
+		status.ticketNumber = ticketTracker.GetTrackingTicketId()
+		status.snoozedUntil = ticketTracker.GetSnoozedUntil()
+
+		var buttons []discordgo.MessageComponent
+		if running {
+			buttons = watchButtons(lang, currentKey())
+		}
+		statusMsg.update(buildStatusEmbed(status), buttons)
+	}
+
+	trackerOpts := []gido.TicketTrackerOption{
+		gido.WithTrackerStartedAt(record.StartedAt),
+		gido.WithTrackerThresholds(thresholds...),
+		gido.WithTrackerNotifyPolicy(notifyPolicy),
+		gido.WithTrackerMaxDuration(MaxWatchDuration),
+		gido.WithTrackerResetAction(ResetAction),
+		// Define the handlers for various events
+		gido.WithTrackerOnStart(func(_ int) {
+			onStart()
+			showStatus(lang.Text("state.watching"), lang.Text("note.waitingUpdate"), statusColorWatching)
+		}),
+		gido.WithTrackerOnStop(func(_ int) {
+			running = false
+			key := currentKey()
+			RemoveTicketTracker(key) // Remove the ticket tracker when stopped
+			if err := watchStore.Delete(key); err != nil {
+				slog.Error("Failed to delete watch", "watch", key, "error", err)
+			}
+
+			switch ticketTracker.GetStopReason() {
+			case gido.StopCompleted:
+				showStatus(lang.Text("state.done"), "", statusColorDone)
+			case gido.StopClosed:
+				showStatus(lang.Text("state.stopped"), lang.Text("note.closedStop"), statusColorStopped)
+				notifyUser(s, userID, record.ChannelID, lang.Text("watch.closedStop", userID, key.TicketNumber))
+			case gido.StopReset:
+				showStatus(lang.Text("state.stopped"), lang.Text("note.resetStop"), statusColorStopped)
+			case gido.StopExpired:
+				showStatus(lang.Text("state.stopped"), lang.Text("note.expiredStop"), statusColorStopped)
+				notifyUser(s, userID, record.ChannelID, lang.Text("watch.expiredStop", userID, key.TicketNumber))
+			default:
+				showStatus(lang.Text("state.stopped"), "", statusColorStopped)
+			}
+		}),
+		gido.WithTrackerOnFetchError(func(err error) {
+			showStatus(lang.Text("state.watching"), lang.Text("note.fetchError", err), statusColorWarning)
+		}),
+		gido.WithTrackerOnFetchInvalidTicketNumber(func() {
+			// the handler answers "----" outside the opening hours, e.g. for a ticket taken before opening
+			if openAt, ok := store.Hours.NextOpen(time.Now()); ok && !store.Hours.IsOpen(time.Now()) {
+				showStatus(lang.Text("state.watching"), lang.Text("note.closed", openAt.Unix()), statusColorWarning)
+				return
+			}
+			showStatus(lang.Text("state.watching"), lang.Text("note.invalidNumber"), statusColorWarning)
+		}),
+		gido.WithTrackerOnMonitorUpdate(func(update gido.TrackerStatus) {
+			status.status = &update
+			note := ""
+			if notifyPolicy.Mode == gido.NotifyDigest && update.Called > 0 {
+				note = lang.Text("note.digest", update.Called)
+			}
+			showStatus(lang.Text("state.watching"), note, statusColorWatching)
+		}),
+		gido.WithTrackerOnThreshold(func(threshold gido.Threshold, update gido.TrackerStatus) {
+			userTicketNumber := ticketTracker.GetTrackingTicketId()
+			msg := lang.Text("alert.groups", userID, userTicketNumber, update.WaitCount, update.CurrentNumber.String())
+			if threshold.Kind == gido.ThresholdMinutes {
+				msg = lang.Text("alert.minutes", userID, userTicketNumber, formatETA(lang, update.ETA), update.WaitCount)
+			}
+			notifyUser(s, userID, record.ChannelID, msg)
+		}),
+		gido.WithTrackerOnReset(func(reset gido.Reset) {
+			reason := lang.Text("reset.numberDrop", reset.Previous.String(), reset.Current.String())
+			if reset.Kind == gido.ResetNewDay {
+				reason = lang.Text("reset.newDay", reset.Previous.String(), reset.Current.String())
+			}
+			msg := lang.Text("watch.resetRearm", userID, reason, ticketTracker.GetTrackingTicketId())
+			if ResetAction == gido.ResetStop {
+				msg = lang.Text("watch.resetStop", userID, reason, ticketTracker.GetTrackingTicketId())
+			}
+			notifyUser(s, userID, record.ChannelID, msg)
+		}),
+		gido.WithTrackerOnTrackComplete(func() {
+			userTicketNumber := ticketTracker.GetTrackingTicketId()
+			msg := lang.Text("alert.complete", userID, userTicketNumber)
+			notifyUser(s, userID, record.ChannelID, msg)
+		}),
+	}
+	// poll at the interval asked for by the user, else faster as the ticket gets closer;
+	// the poller of the store never polls more often than its minimum interval
+	if record.PollSeconds > 0 {
+		trackerOpts = append(trackerOpts, gido.WithTrackerInterval(time.Duration(record.PollSeconds)*time.Second))
+	} else if AdaptivePolling {
+		trackerOpts = append(trackerOpts, gido.WithTrackerAdaptiveInterval(AdaptiveInterval))
+	}
+
+	// Create a ticket tracker instance
+	ticketTracker, err = CreateUserTicketTracker(record.Key(), trackerOpts...)
+	if err != nil {
+		return err
+	}
+
+	if err := watchStore.Save(record); err != nil {
+		slog.Error("Failed to persist watch", "watch", record.Key(), "error", err)
+	}
+
+	// start the ticket tracker
+	ticketTracker.Start()
+	return nil
+}
+
+// updateWatchTicketNumber persists the new ticket number of the watch.
+func updateWatchTicketNumber(key WatchKey, ticketNumber int) {
+	err := watchStore.Update(key, func(record *WatchRecord) {
+		record.TicketNumber = ticketNumber
+	})
+	if err != nil {
+		slog.Error("Failed to persist watch", "watch", key, "error", err)
+	}
+}
+
+// formatETA formats an estimated time until a ticket is called, e.g. "預計約 25 分鐘後叫到".
+func formatETA(lang Language, eta time.Duration) string {
+	minutes := int(math.Ceil(eta.Minutes()))
+	if minutes <= 1 {
+		return lang.Text("eta.soon")
+	}
+	return lang.Text("eta.minutes", minutes)
+}
+
+// restoreWatches restarts the watches persisted before the bot stopped,
+// letting each user know that their watch has resumed.
+// Watches that are already running, e.g. after a reconnect, are left untouched.
+func restoreWatches(s *discordgo.Session) {
+	records, err := watchStore.Load()
+	if err != nil {
+		slog.Error("Failed to load persisted watches", "error", err)
+		return
+	}
+
+	for _, record := range records {
+		if GetTicketTracker(record.Key()) != nil {
+			continue
+		}
+
+		err := startWatch(s, record, func() {
+			storeName := record.StoreID
+			if store, err := Stores.Get(record.StoreID); err == nil {
+				storeName = store.Name
+			}
+			msg := watchLanguage(record).Text("watch.restored", record.UserID, storeName, record.TicketNumber)
+			notifyUser(s, record.UserID, record.ChannelID, msg)
+		})
+		if err != nil {
+			slog.Error("Failed to restore watch", "watch", record.Key(), "error", err)
+			watchStore.Delete(record.Key())
+			continue
+		}
+		log.Printf("Restored watch %s", record.Key())
+	}
+}
@@ -0,0 +1,46 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxListedWatches caps the number of watches listed in a single message.
+const maxListedWatches = 25
+
+// formatWatchList lists the active watches among records, one line per watch with the
+// ticket number, store, groups remaining, ETA and how long ago the watch started.
+// The owner of each watch is mentioned when withOwner is true.
+func formatWatchList(lang Language, records []WatchRecord, withOwner bool) string {
+	var lines []string
+	for _, record := range records {
+		tracker := GetTicketTracker(record.Key())
+		if tracker == nil {
+			continue
+		}
+		if len(lines) == maxListedWatches {
+			lines = append(lines, "...")
+			break
+		}
+
+		storeName := record.StoreID
+		if store, err := Stores.Get(record.StoreID); err == nil {
+			storeName = store.Name
+		}
+
+		progress := lang.Text("watchList.firstUpdate")
+		if status, ok := tracker.GetLatestStatus(); ok {
+			progress = lang.Text("watchList.groupsAhead", status.WaitCount)
+			if status.HasETA {
+				progress += lang.Text("watchList.separator") + formatETA(lang, status.ETA)
+			}
+		}
+
+		line := lang.Text("watchList.line", storeName, tracker.GetTrackingTicketId(), progress, tracker.GetStartedAt().Unix())
+		if withOwner {
+			line = fmt.Sprintf("• <@%s> %s", record.UserID, strings.TrimPrefix(line, "• "))
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
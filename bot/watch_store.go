@@ -0,0 +1,170 @@
+package bot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// WatchRecord is the persisted state of a user's ticket tracker,
+// used to restore the tracker after the bot restarts.
+type WatchRecord struct {
+	UserID       string    `json:"user_id"`
+	GuildID      string    `json:"guild_id"`
+	ChannelID    string    `json:"channel_id"`
+	TicketNumber int       `json:"ticket_number"`
+	StoreID      string    `json:"store_id"`
+	StartedAt    time.Time `json:"started_at"`
+	AlertGroups  []int     `json:"alert_groups,omitempty"`
+	AlertMinutes []int     `json:"alert_minutes,omitempty"`
+	// NotifyMode is the name of the gido.NotifyMode of the monitor updates.
+	NotifyMode string `json:"notify_mode,omitempty"`
+	// NotifyMinutes is the interval of the every and digest notify modes.
+	NotifyMinutes int `json:"notify_minutes,omitempty"`
+	// PollSeconds is how often the watch checks the queue, overriding the adaptive polling if set.
+	PollSeconds int `json:"poll_seconds,omitempty"`
+	// StatusChannelID and StatusMessageID locate the live status message of the watch.
+	StatusChannelID string `json:"status_channel_id,omitempty"`
+	StatusMessageID string `json:"status_message_id,omitempty"`
+	// Language is the language the watch was started in, see watchLanguage.
+	Language string `json:"language,omitempty"`
+}
+
+// Key returns the key of the watch.
+func (record WatchRecord) Key() WatchKey {
+	return WatchKey{UserID: record.UserID, StoreID: record.StoreID, TicketNumber: record.TicketNumber}
+}
+
+// WatchStore persists the active watches to a JSON file.
+// Every change is written to disk immediately, so the file always reflects the running trackers.
+type WatchStore struct {
+	mu      sync.Mutex
+	path    string
+	records map[WatchKey]WatchRecord
+}
+
+// NewWatchStore creates a WatchStore backed by the JSON file at path.
+// The file is created on the first save if it does not exist.
+func NewWatchStore(path string) *WatchStore {
+	return &WatchStore{
+		path:    path,
+		records: map[WatchKey]WatchRecord{},
+	}
+}
+
+// Load reads the persisted watches from disk, replacing the records in memory.
+// A missing file is treated as an empty store.
+func (ws *WatchStore) Load() ([]WatchRecord, error) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	data, err := os.ReadFile(ws.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read watches file: %v", err)
+	}
+
+	var records []WatchRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("failed to parse watches file: %v", err)
+	}
+
+	ws.records = map[WatchKey]WatchRecord{}
+	for _, record := range records {
+		ws.records[record.Key()] = record
+	}
+	return records, nil
+}
+
+// List returns the persisted watches, ordered by start time.
+func (ws *WatchStore) List() []WatchRecord {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	records := make([]WatchRecord, 0, len(ws.records))
+	for _, record := range ws.records {
+		records = append(records, record)
+	}
+	sort.Slice(records, func(a, b int) bool {
+		return records[a].StartedAt.Before(records[b].StartedAt)
+	})
+	return records
+}
+
+// Save adds or replaces the watch and writes the store to disk.
+func (ws *WatchStore) Save(record WatchRecord) error {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	ws.records[record.Key()] = record
+	return ws.flush()
+}
+
+// Update applies fn to the watch and writes the store to disk.
+// The watch is stored under its new key if fn changes it.
+// It does nothing if the watch is not persisted.
+func (ws *WatchStore) Update(key WatchKey, fn func(record *WatchRecord)) error {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	record, exists := ws.records[key]
+	if !exists {
+		return nil
+	}
+	fn(&record)
+	delete(ws.records, key)
+	ws.records[record.Key()] = record
+	return ws.flush()
+}
+
+// Delete removes the watch and writes the store to disk.
+func (ws *WatchStore) Delete(key WatchKey) error {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	if _, exists := ws.records[key]; !exists {
+		return nil
+	}
+	delete(ws.records, key)
+	return ws.flush()
+}
+
+// flush writes the records to the store file, see writeFileAtomic. The caller must hold ws.mu.
+func (ws *WatchStore) flush() error {
+	records := make([]WatchRecord, 0, len(ws.records))
+	for _, record := range ws.records {
+		records = append(records, record)
+	}
+
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode watches: %v", err)
+	}
+	if err := writeFileAtomic(ws.path, data); err != nil {
+		return fmt.Errorf("failed to write watches file: %v", err)
+	}
+	return nil
+}
+
+// writeFileAtomic writes data to a temporary file and renames it over the file at path,
+// so a crash never leaves a half-written file behind. The directory of path is created if needed.
+func writeFileAtomic(path string, data []byte) error {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
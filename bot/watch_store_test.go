@@ -0,0 +1,117 @@
+package bot
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/SDxBacon/gido-guardian-bot/gido"
+)
+
+// useWatchStore replaces the watch store of the bot with one backed by a temporary file.
+func useWatchStore(t *testing.T) *WatchStore {
+	t.Helper()
+
+	previous := watchStore
+	watchStore = NewWatchStore(filepath.Join(t.TempDir(), "data", "watches.json"))
+	t.Cleanup(func() { watchStore = previous })
+	return watchStore
+}
+
+func TestWatchStoreRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "watches.json")
+	ws := NewWatchStore(path)
+
+	if records, err := ws.Load(); err != nil || len(records) != 0 {
+		t.Fatalf("loaded %v (error %v) from a missing file, want nothing", records, err)
+	}
+
+	startedAt := time.Date(2025, time.January, 7, 12, 0, 0, 0, time.UTC)
+	first := WatchRecord{UserID: "user", StoreID: "gido", TicketNumber: 120, StartedAt: startedAt, AlertGroups: []int{5, 2}, NotifyMode: "every", NotifyMinutes: 10}
+	second := WatchRecord{UserID: "user", StoreID: "gido", TicketNumber: 130, StartedAt: startedAt.Add(time.Minute)}
+	third := WatchRecord{UserID: "other", StoreID: "taipei", TicketNumber: 7, StartedAt: startedAt.Add(2 * time.Minute)}
+	for _, record := range []WatchRecord{first, second, third} {
+		if err := ws.Save(record); err != nil {
+			t.Fatalf("failed to save %v: %v", record.Key(), err)
+		}
+	}
+
+	// the ticket number is part of the key, so the watch moves to its new key
+	if err := ws.Update(first.Key(), func(record *WatchRecord) { record.TicketNumber = 125 }); err != nil {
+		t.Fatalf("failed to update: %v", err)
+	}
+	if err := ws.Delete(second.Key()); err != nil {
+		t.Fatalf("failed to delete: %v", err)
+	}
+	if err := ws.Update(second.Key(), func(record *WatchRecord) { t.Fatalf("updated a deleted watch") }); err != nil {
+		t.Fatalf("failed to update a deleted watch: %v", err)
+	}
+
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Fatalf("temporary file left behind: %v", err)
+	}
+
+	records, err := NewWatchStore(path).Load()
+	if err != nil {
+		t.Fatalf("failed to load: %v", err)
+	}
+	slices.SortFunc(records, func(a, b WatchRecord) int { return a.StartedAt.Compare(b.StartedAt) })
+
+	first.TicketNumber = 125
+	want := []WatchRecord{first, third}
+	if len(records) != len(want) {
+		t.Fatalf("loaded %+v, want %+v", records, want)
+	}
+	for idx := range want {
+		got := records[idx]
+		if got.Key() != want[idx].Key() || !got.StartedAt.Equal(want[idx].StartedAt) ||
+			!slices.Equal(got.AlertGroups, want[idx].AlertGroups) || got.NotifyMode != want[idx].NotifyMode || got.NotifyMinutes != want[idx].NotifyMinutes {
+			t.Fatalf("loaded %+v, want %+v", got, want[idx])
+		}
+	}
+}
+
+func TestWatchStoreLoadInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "watches.json")
+	if err := os.WriteFile(path, []byte("not json"), 0o644); err != nil {
+		t.Fatalf("failed to write watches file: %v", err)
+	}
+	if _, err := NewWatchStore(path).Load(); err == nil {
+		t.Fatalf("loaded an invalid watches file")
+	}
+}
+
+func TestRestoreWatchesSkipsRunningTracker(t *testing.T) {
+	ws := useWatchStore(t)
+	running := WatchRecord{UserID: "user", StoreID: "gido", TicketNumber: 120, ChannelID: "channel"}
+	unknownStore := WatchRecord{UserID: "user", StoreID: "closed-for-good", TicketNumber: 130, ChannelID: "channel"}
+	for _, record := range []WatchRecord{running, unknownStore} {
+		if err := ws.Save(record); err != nil {
+			t.Fatalf("failed to save %v: %v", record.Key(), err)
+		}
+	}
+
+	// the tracker of the watch is still running, e.g. after a reconnect
+	tracker := gido.NewTicketTracker(running.TicketNumber)
+	mutex.Lock()
+	ticketTrackersMap[running.Key()] = tracker
+	mutex.Unlock()
+	t.Cleanup(func() { RemoveTicketTracker(running.Key()) })
+
+	s, fake := newFakeSession(t, nil)
+	restoreWatches(s)
+
+	if GetTicketTracker(running.Key()) != tracker {
+		t.Fatalf("running tracker was replaced")
+	}
+	if requests := fake.Requests(); len(requests) > 0 {
+		t.Fatalf("restoring sent %v, want the running watch left untouched", requests)
+	}
+
+	records := ws.List()
+	if len(records) != 1 || records[0].Key() != running.Key() {
+		t.Fatalf("persisted %+v, want the running watch kept and the unrestorable one deleted", records)
+	}
+}
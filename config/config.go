@@ -0,0 +1,334 @@
+// Package config loads the configuration of the bot from a YAML file, the environment
+// variables and the command line flags, in increasing order of precedence.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/SDxBacon/gido-guardian-bot/bot"
+	"github.com/SDxBacon/gido-guardian-bot/gido"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultFile is the configuration file read when none is given and it exists.
+const DefaultFile = "config.yaml"
+
+// redacted replaces the secrets in the output of Write.
+const redacted = "<redacted>"
+
+// Config is the configuration of the bot. See config.example.yaml for a documented example.
+type Config struct {
+	Discord     Discord      `yaml:"discord"`
+	Stores      []gido.Store `yaml:"stores,omitempty"`
+	StoresFile  string       `yaml:"stores_file,omitempty"`
+	Upstream    Upstream     `yaml:"upstream"`
+	Polling     Polling      `yaml:"polling"`
+	Watches     Watches      `yaml:"watches"`
+	Permissions Permissions  `yaml:"permissions"`
+	Storage     Storage      `yaml:"storage"`
+	Log         Log          `yaml:"log"`
+}
+
+// Discord configures the connection to Discord and the behavior of the bot per guild.
+type Discord struct {
+	// Token is the bot token, redacted by Redacted.
+	Token string `yaml:"token"`
+	// GuildIDs are the guilds the commands are registered in; they are registered globally if empty.
+	GuildIDs []string `yaml:"guild_ids,omitempty"`
+	// GuildLanguages maps guild IDs to the language of the bot in the guild.
+	GuildLanguages map[string]bot.Language `yaml:"guild_languages,omitempty"`
+	// EphemeralResponses maps guild IDs to whether the response of each command is only shown to its user.
+	EphemeralResponses map[string]map[string]bool `yaml:"ephemeral_responses,omitempty"`
+}
+
+// Upstream configures where and how the wait info is fetched.
+type Upstream struct {
+	// BaseURL overrides the handler URL, see gido.WithBaseURL.
+	BaseURL string `yaml:"base_url,omitempty"`
+	// ReplayFile replays recorded wait info instead of querying the handler.
+	ReplayFile string `yaml:"replay_file,omitempty"`
+	// TimeZone is the IANA time zone of the stores, gido.DefaultLocation if empty.
+	TimeZone string `yaml:"timezone,omitempty"`
+	// Retries is "attempts" or "attempts,baseDelay,maxDelay", see gido.ParseRetryOptions.
+	Retries string `yaml:"retries"`
+	// Breaker is "threshold,cooldown", see gido.ParseBreakerOption.
+	Breaker string `yaml:"breaker"`
+}
+
+// Polling configures how often the stores are polled.
+type Polling struct {
+	Interval    Duration `yaml:"interval"`
+	MinInterval Duration `yaml:"min_interval"`
+	// Adaptive is "true", "false" or "far,near,nearGroups", see gido.ParseAdaptiveInterval.
+	Adaptive string `yaml:"adaptive"`
+}
+
+// Watches configures the limits of the watches.
+type Watches struct {
+	MaxPerUser  int      `yaml:"max_per_user"`
+	MaxDuration Duration `yaml:"max_duration"`
+	// OnReset is "rearm" or "stop", see gido.ParseResetAction.
+	OnReset string `yaml:"on_reset"`
+}
+
+// Permissions configures who can use the admin commands.
+type Permissions struct {
+	// AdminRoles maps guild IDs to the roles allowed to use the admin commands.
+	AdminRoles map[string][]string `yaml:"admin_roles,omitempty"`
+}
+
+// Storage configures where the state of the bot is persisted.
+type Storage struct {
+	WatchesFile     string `yaml:"watches_file"`
+	PreferencesFile string `yaml:"preferences_file"`
+	// HistoryFile is where the queue history is recorded; recording is disabled if empty.
+	HistoryFile string `yaml:"history_file"`
+}
+
+// Log configures the logging.
+type Log struct {
+	// Level is "debug", "info", "warn" or "error".
+	Level string `yaml:"level"`
+}
+
+// SlogLevel returns the level as a slog.Level.
+func (l Log) SlogLevel() (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(l.Level)); err != nil {
+		return level, fmt.Errorf("invalid log level %q, expected debug, info, warn or error", l.Level)
+	}
+	return level, nil
+}
+
+// Duration is a time.Duration written as a string such as "1m30s" in the configuration file.
+type Duration time.Duration
+
+func (d Duration) MarshalYAML() (any, error) {
+	return time.Duration(d).String(), nil
+}
+
+func (d *Duration) UnmarshalYAML(value *yaml.Node) error {
+	duration, err := time.ParseDuration(value.Value)
+	if err != nil {
+		return fmt.Errorf("line %d: invalid duration %q", value.Line, value.Value)
+	}
+	*d = Duration(duration)
+	return nil
+}
+
+// Default returns the configuration used when nothing is configured.
+func Default() *Config {
+	return &Config{
+		Upstream: Upstream{
+			Retries: fmt.Sprintf("%d,%v,%v", gido.DefaultRetryAttempts, gido.DefaultRetryBaseDelay, gido.DefaultRetryMaxDelay),
+			Breaker: fmt.Sprintf("%d,%v", gido.DefaultBreakerThreshold, gido.DefaultBreakerCooldown),
+		},
+		Polling: Polling{
+			Interval:    Duration(gido.DefaultPollInterval),
+			MinInterval: Duration(gido.DefaultMinPollInterval),
+			Adaptive:    "true",
+		},
+		Watches: Watches{
+			MaxPerUser:  bot.MaxWatchesPerUser,
+			MaxDuration: Duration(bot.MaxWatchDuration),
+			OnReset:     "rearm",
+		},
+		Storage: Storage{
+			WatchesFile:     bot.WatchesFile,
+			PreferencesFile: bot.PreferencesFile,
+			HistoryFile:     bot.HistoryFile,
+		},
+		Log: Log{
+			Level: "info",
+		},
+	}
+}
+
+// Load reads the configuration from the defaults, then the configuration file, then the
+// environment variables and finally the command line flags args. The configuration file is
+// given by the -config flag or GIDO_CONFIG, and defaults to DefaultFile if it exists.
+// The configuration is not validated, see Validate.
+//
+// Returns:
+//   - *Config: The loaded configuration.
+//   - bool: Whether the -print-config flag was given.
+//   - error: An error if a flag, the file or a variable is invalid, flag.ErrHelp if -help was given.
+func Load(args []string) (*Config, bool, error) {
+	fs, configFile, printConfig := newFlagSet()
+	if err := fs.Parse(args); err != nil {
+		return nil, false, err
+	}
+
+	path, required := *configFile, true
+	if path == "" {
+		path = os.Getenv("GIDO_CONFIG")
+	}
+	if path == "" {
+		path, required = DefaultFile, false
+	}
+
+	cfg := Default()
+	if err := cfg.readFile(path, required); err != nil {
+		return nil, false, err
+	}
+	if err := cfg.applyEnv(); err != nil {
+		return nil, false, err
+	}
+	if err := cfg.applyFlags(fs); err != nil {
+		return nil, false, err
+	}
+
+	if cfg.StoresFile != "" {
+		if len(cfg.Stores) > 0 {
+			return nil, false, fmt.Errorf("stores and stores_file cannot be both set")
+		}
+		stores, err := gido.LoadStores(cfg.StoresFile)
+		if err != nil {
+			return nil, false, err
+		}
+		cfg.Stores = stores
+	}
+	if len(cfg.Stores) == 0 {
+		cfg.Stores = []gido.Store{gido.DefaultStore}
+	}
+	return cfg, *printConfig, nil
+}
+
+// readFile reads the configuration file at path over cfg. A missing file is an error only if required.
+// Unknown keys are rejected, so that a typo does not silently leave a setting to its default.
+func (cfg *Config) readFile(path string, required bool) error {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) && !required {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open config file: %v", err)
+	}
+	defer file.Close()
+
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to parse config file %s: %v", path, err)
+	}
+
+	// the languages are matched ignoring case, keep the names of the catalogs, e.g. "en" for "EN";
+	// the invalid ones are left for Validate to report
+	for guildID, lang := range cfg.Discord.GuildLanguages {
+		if parsed, err := bot.ParseLanguage(string(lang)); err == nil {
+			cfg.Discord.GuildLanguages[guildID] = parsed
+		}
+	}
+	return nil
+}
+
+// Validate checks the whole configuration, reporting every invalid setting at once.
+func (cfg *Config) Validate() error {
+	var errs []error
+	check := func(err error) {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if cfg.Discord.Token == "" {
+		check(fmt.Errorf("discord.token is required, set it in the config file, BOT_TOKEN or -token"))
+	}
+	for guildID, lang := range cfg.Discord.GuildLanguages {
+		if _, err := bot.ParseLanguage(string(lang)); err != nil {
+			check(fmt.Errorf("discord.guild_languages.%s: %v", guildID, err))
+		}
+	}
+
+	ids := map[string]bool{}
+	for idx, store := range cfg.Stores {
+		if store.ID == "" || store.DepCode == "" {
+			check(fmt.Errorf("stores[%d]: a store must have an id and a dep_code", idx))
+		}
+		if ids[store.ID] {
+			check(fmt.Errorf("stores[%d]: duplicate store id %q", idx, store.ID))
+		}
+		ids[store.ID] = true
+	}
+
+	if cfg.Upstream.TimeZone != "" {
+		if _, err := time.LoadLocation(cfg.Upstream.TimeZone); err != nil {
+			check(fmt.Errorf("upstream.timezone: %v", err))
+		}
+	}
+	if _, err := gido.ParseRetryOptions(cfg.Upstream.Retries); err != nil {
+		check(fmt.Errorf("upstream.retries: %v", err))
+	}
+	if _, err := gido.ParseBreakerOption(cfg.Upstream.Breaker); err != nil {
+		check(fmt.Errorf("upstream.breaker: %v", err))
+	}
+
+	if cfg.Polling.Interval <= 0 {
+		check(fmt.Errorf("polling.interval must be positive"))
+	}
+	if cfg.Polling.MinInterval < 0 {
+		check(fmt.Errorf("polling.min_interval must not be negative"))
+	}
+	if _, _, err := cfg.Polling.AdaptiveInterval(); err != nil {
+		check(fmt.Errorf("polling.adaptive: %v", err))
+	}
+
+	if cfg.Watches.MaxPerUser < 1 {
+		check(fmt.Errorf("watches.max_per_user must be at least 1"))
+	}
+	if cfg.Watches.MaxDuration < 0 {
+		check(fmt.Errorf("watches.max_duration must not be negative"))
+	}
+	if _, err := gido.ParseResetAction(cfg.Watches.OnReset); err != nil {
+		check(fmt.Errorf("watches.on_reset: %v", err))
+	}
+
+	if cfg.Storage.WatchesFile == "" || cfg.Storage.PreferencesFile == "" {
+		check(fmt.Errorf("storage.watches_file and storage.preferences_file are required"))
+	}
+	if _, err := cfg.Log.SlogLevel(); err != nil {
+		check(fmt.Errorf("log.level: %v", err))
+	}
+	return errors.Join(errs...)
+}
+
+// AdaptiveInterval returns whether the adaptive polling is enabled, and its settings.
+func (p Polling) AdaptiveInterval() (bool, gido.AdaptiveInterval, error) {
+	if p.Adaptive == "" {
+		return true, gido.DefaultAdaptiveInterval, nil
+	}
+	if enabled, err := strconv.ParseBool(p.Adaptive); err == nil {
+		return enabled, gido.DefaultAdaptiveInterval, nil
+	}
+	adaptive, err := gido.ParseAdaptiveInterval(p.Adaptive)
+	return err == nil, adaptive, err
+}
+
+// Redacted returns a copy of the configuration without its secrets, e.g. to print it.
+func (cfg *Config) Redacted() *Config {
+	copied := *cfg
+	if copied.Discord.Token != "" {
+		copied.Discord.Token = redacted
+	}
+	if baseURL, err := url.Parse(copied.Upstream.BaseURL); err == nil && baseURL.User != nil {
+		copied.Upstream.BaseURL = baseURL.Redacted()
+	}
+	return &copied
+}
+
+// Write writes the configuration as YAML. Use Redacted first to leave out the secrets.
+func (cfg *Config) Write(w io.Writer) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(cfg); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
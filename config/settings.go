@@ -0,0 +1,177 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SDxBacon/gido-guardian-bot/bot"
+)
+
+// setting is a configuration value that can be overridden by an environment variable,
+// and by a command line flag if flag is set.
+type setting struct {
+	env  string
+	flag string
+	// usage describes the flag.
+	usage string
+	// allowEmpty applies the variable even when it is set to an empty string, e.g. to disable a feature.
+	allowEmpty bool
+	set        func(cfg *Config, value string) error
+}
+
+// settings are the configuration values overridable by environment variables and flags.
+// The variables predate the configuration file and keep their original formats.
+var settings = []setting{
+	{env: "BOT_TOKEN", flag: "token", usage: "Discord bot token", set: func(cfg *Config, value string) error {
+		cfg.Discord.Token = value
+		return nil
+	}},
+	{env: "GIDO_GUILD_IDS", flag: "guild-ids", usage: "comma separated guild IDs to register the commands in, globally if empty", allowEmpty: true, set: func(cfg *Config, value string) error {
+		cfg.Discord.GuildIDs = splitList(value)
+		return nil
+	}},
+	{env: "GIDO_GUILD_LANGUAGES", set: func(cfg *Config, value string) (err error) {
+		cfg.Discord.GuildLanguages, err = bot.ParseGuildLanguages(value)
+		return err
+	}},
+	{env: "GIDO_EPHEMERAL_RESPONSES", set: func(cfg *Config, value string) (err error) {
+		cfg.Discord.EphemeralResponses, err = bot.ParseEphemeralResponses(value)
+		return err
+	}},
+	{env: "GIDO_STORES_FILE", flag: "stores-file", usage: "JSON file of the stores to follow", set: func(cfg *Config, value string) error {
+		cfg.StoresFile = value
+		return nil
+	}},
+	{env: "GIDO_BASE_URL", set: func(cfg *Config, value string) error {
+		cfg.Upstream.BaseURL = value
+		return nil
+	}},
+	{env: "GIDO_REPLAY_FILE", flag: "replay-file", usage: "replay the wait info recorded in this file instead of querying GIDO", set: func(cfg *Config, value string) error {
+		cfg.Upstream.ReplayFile = value
+		return nil
+	}},
+	{env: "GIDO_TIMEZONE", set: func(cfg *Config, value string) error {
+		cfg.Upstream.TimeZone = value
+		return nil
+	}},
+	{env: "GIDO_FETCH_RETRIES", set: func(cfg *Config, value string) error {
+		cfg.Upstream.Retries = value
+		return nil
+	}},
+	{env: "GIDO_BREAKER", set: func(cfg *Config, value string) error {
+		cfg.Upstream.Breaker = value
+		return nil
+	}},
+	{env: "GIDO_POLL_INTERVAL", flag: "poll-interval", usage: "how often the stores are polled, e.g. 1m", set: func(cfg *Config, value string) error {
+		return setDuration(&cfg.Polling.Interval, value)
+	}},
+	{env: "GIDO_MIN_POLL_INTERVAL", set: func(cfg *Config, value string) error {
+		return setDuration(&cfg.Polling.MinInterval, value)
+	}},
+	{env: "GIDO_ADAPTIVE_POLLING", set: func(cfg *Config, value string) error {
+		cfg.Polling.Adaptive = value
+		return nil
+	}},
+	{env: "GIDO_MAX_WATCHES_PER_USER", set: func(cfg *Config, value string) (err error) {
+		cfg.Watches.MaxPerUser, err = strconv.Atoi(value)
+		return err
+	}},
+	{env: "GIDO_MAX_WATCH_DURATION", set: func(cfg *Config, value string) error {
+		return setDuration(&cfg.Watches.MaxDuration, value)
+	}},
+	{env: "GIDO_ON_RESET", set: func(cfg *Config, value string) error {
+		cfg.Watches.OnReset = value
+		return nil
+	}},
+	{env: "GIDO_ADMIN_ROLES", set: func(cfg *Config, value string) (err error) {
+		cfg.Permissions.AdminRoles, err = bot.ParseAdminRoles(value)
+		return err
+	}},
+	{env: "GIDO_WATCHES_FILE", flag: "watches-file", usage: "JSON file the active watches are persisted to", set: func(cfg *Config, value string) error {
+		cfg.Storage.WatchesFile = value
+		return nil
+	}},
+	{env: "GIDO_PREFERENCES_FILE", set: func(cfg *Config, value string) error {
+		cfg.Storage.PreferencesFile = value
+		return nil
+	}},
+	{env: "GIDO_HISTORY_FILE", flag: "history-file", usage: "file the queue history is recorded to, disabled if empty", allowEmpty: true, set: func(cfg *Config, value string) error {
+		cfg.Storage.HistoryFile = value
+		return nil
+	}},
+	{env: "GIDO_LOG_LEVEL", flag: "log-level", usage: "debug, info, warn or error", set: func(cfg *Config, value string) error {
+		cfg.Log.Level = value
+		return nil
+	}},
+}
+
+// newFlagSet defines the command line flags: -config, -print-config and one flag per setting having one.
+func newFlagSet() (fs *flag.FlagSet, configFile *string, printConfig *bool) {
+	fs = flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	configFile = fs.String("config", "", fmt.Sprintf("YAML configuration file, defaults to GIDO_CONFIG or %s if it exists", DefaultFile))
+	printConfig = fs.Bool("print-config", false, "print the configuration without its secrets, validate it and exit")
+	for _, s := range settings {
+		if s.flag != "" {
+			fs.String(s.flag, "", fmt.Sprintf("%s (overrides %s)", s.usage, s.env))
+		}
+	}
+	return fs, configFile, printConfig
+}
+
+// applyEnv overrides cfg with the environment variables that are set.
+func (cfg *Config) applyEnv() error {
+	for _, s := range settings {
+		value, ok := os.LookupEnv(s.env)
+		if !ok || (value == "" && !s.allowEmpty) {
+			continue
+		}
+		if err := s.set(cfg, value); err != nil {
+			return fmt.Errorf("invalid %s: %v", s.env, err)
+		}
+	}
+	return nil
+}
+
+// applyFlags overrides cfg with the flags given on the command line.
+func (cfg *Config) applyFlags(fs *flag.FlagSet) error {
+	given := map[string]string{}
+	fs.Visit(func(f *flag.Flag) {
+		given[f.Name] = f.Value.String()
+	})
+
+	for _, s := range settings {
+		value, ok := given[s.flag]
+		if s.flag == "" || !ok {
+			continue
+		}
+		if err := s.set(cfg, value); err != nil {
+			return fmt.Errorf("invalid -%s: %v", s.flag, err)
+		}
+	}
+	return nil
+}
+
+// setDuration parses value into d.
+func setDuration(d *Duration, value string) error {
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	*d = Duration(duration)
+	return nil
+}
+
+// splitList splits a comma separated list, dropping the empty items.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
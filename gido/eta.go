@@ -0,0 +1,111 @@
+package gido
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultRateWindow is how far back a ServiceRateEstimator looks when computing the service rate.
+const DefaultRateWindow = 30 * time.Minute
+
+// minAdvancesForRate is the number of observed advances required before a rate is reported,
+// so a single jump right after the watch starts doesn't produce a wild estimate.
+const minAdvancesForRate = 2
+
+type advance struct {
+	at    time.Time
+	count int
+}
+
+// ServiceRateEstimator records the timestamps of observed CurrentNumber advances
+// and computes a rolling service rate over a time window.
+type ServiceRateEstimator struct {
+	mu         sync.Mutex
+	window     time.Duration
+	lastNumber int
+	firstSeen  time.Time
+	advances   []advance
+}
+
+func NewServiceRateEstimator(window time.Duration) *ServiceRateEstimator {
+	return &ServiceRateEstimator{
+		window: window,
+	}
+}
+
+// Observe records the current number seen at the given time.
+// Invalid numbers are ignored, and so is a number going back by at most resetTolerance,
+// e.g. an out of order response, so a single glitch does not wipe the history.
+// A number going back further is a reset of the numbering, which resets the estimator.
+func (e *ServiceRateEstimator) Observe(at time.Time, currentNumber int) {
+	if currentNumber <= 0 {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	// a small step back keeps the highest number seen, so the advance after it is not counted twice
+	switch {
+	case e.firstSeen.IsZero() || currentNumber < e.lastNumber-resetTolerance:
+		e.firstSeen = at
+		e.advances = nil
+		e.lastNumber = currentNumber
+	case currentNumber > e.lastNumber:
+		e.advances = append(e.advances, advance{at: at, count: currentNumber - e.lastNumber})
+		e.lastNumber = currentNumber
+	}
+	e.prune(at)
+}
+
+// prune drops the advances that fell out of the window. The caller must hold e.mu.
+func (e *ServiceRateEstimator) prune(now time.Time) {
+	cutoff := now.Add(-e.window)
+	idx := 0
+	for idx < len(e.advances) && e.advances[idx].at.Before(cutoff) {
+		idx++
+	}
+	e.advances = e.advances[idx:]
+}
+
+// Rate returns the number of groups served per minute over the window.
+// ok is false until enough advances have been observed.
+func (e *ServiceRateEstimator) Rate() (perMinute float64, ok bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := time.Now()
+	e.prune(now)
+	if len(e.advances) < minAdvancesForRate {
+		return 0, false
+	}
+
+	served := 0
+	for _, adv := range e.advances {
+		served += adv.count
+	}
+
+	// measure from the start of the window, or from the first observation if it is more recent
+	since := now.Add(-e.window)
+	if e.firstSeen.After(since) {
+		since = e.firstSeen
+	}
+	elapsed := now.Sub(since).Minutes()
+	if elapsed <= 0 {
+		return 0, false
+	}
+	return float64(served) / elapsed, true
+}
+
+// EstimateWait estimates how long it takes for the given number of groups to be served.
+// ok is false when no rate is known yet.
+func (e *ServiceRateEstimator) EstimateWait(groups int) (eta time.Duration, ok bool) {
+	if groups <= 0 {
+		return 0, true
+	}
+	rate, ok := e.Rate()
+	if !ok {
+		return 0, false
+	}
+	return time.Duration(float64(groups) / rate * float64(time.Minute)), true
+}
@@ -0,0 +1,43 @@
+package gido
+
+import (
+	"testing"
+	"time"
+)
+
+func TestServiceRateEstimatorObserve(t *testing.T) {
+	tests := []struct {
+		name    string
+		numbers []int
+		wantOK  bool
+		// wantServed is the number of groups counted as served, if wantOK.
+		wantServed int
+	}{
+		{name: "steady advances", numbers: []int{10, 12, 15}, wantOK: true, wantServed: 5},
+		{name: "glitch within tolerance", numbers: []int{10, 12, 9, 15}, wantOK: true, wantServed: 5},
+		{name: "reset of the numbering", numbers: []int{50, 52, 55, 3}, wantOK: false},
+		{name: "invalid numbers ignored", numbers: []int{10, -1, 12, 0, 15}, wantOK: true, wantServed: 5},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e := NewServiceRateEstimator(DefaultRateWindow)
+			start := time.Now().Add(-10 * time.Minute)
+			for idx, number := range test.numbers {
+				e.Observe(start.Add(time.Duration(idx)*time.Minute), number)
+			}
+
+			rate, ok := e.Rate()
+			if ok != test.wantOK {
+				t.Fatalf("rate known %v, want %v", ok, test.wantOK)
+			}
+			if !ok {
+				return
+			}
+			// the rate is measured over the 10 minutes since the first observation
+			if served := rate * 10; served < float64(test.wantServed)-0.1 || served > float64(test.wantServed)+0.1 {
+				t.Fatalf("served %.2f groups, want %d", served, test.wantServed)
+			}
+		})
+	}
+}
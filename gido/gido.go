@@ -1,7 +1,8 @@
 package gido
 
+// GetCurrentWaitInfo fetches the current wait info from the DefaultWaitInfoSource.
 func GetCurrentWaitInfo() (WaitInfo, error) {
-	return fetchWaitInfo()
+	return DefaultWaitInfoSource.FetchWaitInfo()
 }
 
 // func StopWatchTicket(s *discordgo.Session, i *discordgo.InteractionCreate) {
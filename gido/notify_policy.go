@@ -0,0 +1,78 @@
+package gido
+
+import (
+	"fmt"
+	"time"
+)
+
+// NotifyMode selects when a TicketTracker reports monitor updates.
+type NotifyMode int
+
+const (
+	// NotifyOnChange reports an update only when the current number advances.
+	NotifyOnChange NotifyMode = iota
+	// NotifyEvery reports an update every interval, whether the number moved or not.
+	NotifyEvery
+	// NotifyDigest reports once per interval how many groups were called, skipping quiet intervals.
+	NotifyDigest
+)
+
+var notifyModeNames = map[NotifyMode]string{
+	NotifyOnChange: "change",
+	NotifyEvery:    "every",
+	NotifyDigest:   "digest",
+}
+
+func (m NotifyMode) String() string {
+	if name, exists := notifyModeNames[m]; exists {
+		return name
+	}
+	return fmt.Sprintf("NotifyMode(%d)", int(m))
+}
+
+// ParseNotifyMode parses the name of a notify mode, as returned by NotifyMode.String.
+// An empty name yields NotifyOnChange.
+func ParseNotifyMode(name string) (NotifyMode, error) {
+	if name == "" {
+		return NotifyOnChange, nil
+	}
+	for mode, modeName := range notifyModeNames {
+		if modeName == name {
+			return mode, nil
+		}
+	}
+	return NotifyOnChange, fmt.Errorf("unknown notify mode %q", name)
+}
+
+// DefaultNotifyInterval is the interval of NotifyEvery and NotifyDigest when none is given.
+const DefaultNotifyInterval = 10 * time.Minute
+
+// NotifyPolicy decides which monitor updates a TicketTracker reports.
+type NotifyPolicy struct {
+	Mode NotifyMode
+	// Interval is the reporting interval of NotifyEvery and NotifyDigest.
+	Interval time.Duration
+}
+
+// shouldNotify reports whether the update should be reported, given the last reported one.
+// last is nil if nothing has been reported yet.
+func (p NotifyPolicy) shouldNotify(status TrackerStatus, last *TrackerStatus) bool {
+	if last == nil {
+		return true
+	}
+
+	interval := p.Interval
+	if interval <= 0 {
+		interval = DefaultNotifyInterval
+	}
+	elapsed := status.UpdatedAt.Sub(last.UpdatedAt) >= interval
+
+	switch p.Mode {
+	case NotifyEvery:
+		return elapsed
+	case NotifyDigest:
+		return elapsed && status.CurrentNumber != last.CurrentNumber
+	default:
+		return status.CurrentNumber != last.CurrentNumber
+	}
+}
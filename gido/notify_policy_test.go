@@ -0,0 +1,56 @@
+package gido
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNotifyPolicyShouldNotify(t *testing.T) {
+	start := time.Date(2025, time.January, 7, 12, 0, 0, 0, time.UTC)
+	status := func(currentNumber int, elapsed time.Duration) TrackerStatus {
+		return TrackerStatus{CurrentNumber: WaitInfoIntField(currentNumber), UpdatedAt: start.Add(elapsed)}
+	}
+	last := status(100, 0)
+
+	tests := []struct {
+		name   string
+		policy NotifyPolicy
+		status TrackerStatus
+		last   *TrackerStatus
+		want   bool
+	}{
+		{name: "on change, first update", policy: NotifyPolicy{Mode: NotifyOnChange}, status: status(100, 0), want: true},
+		{name: "on change, number moved", policy: NotifyPolicy{Mode: NotifyOnChange}, status: status(101, time.Second), last: &last, want: true},
+		{name: "on change, number unchanged", policy: NotifyPolicy{Mode: NotifyOnChange}, status: status(100, time.Hour), last: &last, want: false},
+		{name: "every, first update", policy: NotifyPolicy{Mode: NotifyEvery, Interval: 5 * time.Minute}, status: status(100, 0), want: true},
+		{name: "every, within the interval", policy: NotifyPolicy{Mode: NotifyEvery, Interval: 5 * time.Minute}, status: status(105, 4*time.Minute), last: &last, want: false},
+		{name: "every, interval elapsed without change", policy: NotifyPolicy{Mode: NotifyEvery, Interval: 5 * time.Minute}, status: status(100, 5*time.Minute), last: &last, want: true},
+		{name: "every, default interval", policy: NotifyPolicy{Mode: NotifyEvery}, status: status(100, DefaultNotifyInterval-time.Second), last: &last, want: false},
+		{name: "digest, first update", policy: NotifyPolicy{Mode: NotifyDigest, Interval: 5 * time.Minute}, status: status(100, 0), want: true},
+		{name: "digest, within the interval", policy: NotifyPolicy{Mode: NotifyDigest, Interval: 5 * time.Minute}, status: status(105, 4*time.Minute), last: &last, want: false},
+		{name: "digest, interval elapsed with calls", policy: NotifyPolicy{Mode: NotifyDigest, Interval: 5 * time.Minute}, status: status(105, 5*time.Minute), last: &last, want: true},
+		{name: "digest, quiet interval", policy: NotifyPolicy{Mode: NotifyDigest, Interval: 5 * time.Minute}, status: status(100, 10*time.Minute), last: &last, want: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.policy.shouldNotify(test.status, test.last); got != test.want {
+				t.Fatalf("notify %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseNotifyMode(t *testing.T) {
+	for _, mode := range []NotifyMode{NotifyOnChange, NotifyEvery, NotifyDigest} {
+		if parsed, err := ParseNotifyMode(mode.String()); err != nil || parsed != mode {
+			t.Fatalf("parsed %q as %v (error %v), want %v", mode.String(), parsed, err, mode)
+		}
+	}
+	if mode, err := ParseNotifyMode(""); err != nil || mode != NotifyOnChange {
+		t.Fatalf("parsed an empty name as %v (error %v), want %v", mode, err, NotifyOnChange)
+	}
+	if _, err := ParseNotifyMode("threshold"); err == nil {
+		t.Fatalf("parsed an unknown notify mode")
+	}
+}
@@ -0,0 +1,60 @@
+package gido
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AdaptiveInterval makes a TicketTracker poll slowly while its ticket is far away,
+// and faster once only a few groups remain before it.
+type AdaptiveInterval struct {
+	// Far is the interval while more than NearGroups groups remain.
+	Far time.Duration
+	// Near is the interval once at most NearGroups groups remain.
+	Near       time.Duration
+	NearGroups int
+}
+
+// DefaultAdaptiveInterval polls every 2 minutes, and every 15 seconds once 5 groups or less remain.
+var DefaultAdaptiveInterval = AdaptiveInterval{
+	Far:        2 * time.Minute,
+	Near:       15 * time.Second,
+	NearGroups: 5,
+}
+
+// interval returns the interval to poll at while waitCount groups remain.
+func (a AdaptiveInterval) interval(waitCount int) time.Duration {
+	if waitCount <= a.NearGroups {
+		return a.Near
+	}
+	return a.Far
+}
+
+// ParseAdaptiveInterval parses adaptive polling settings formatted as "far,near,nearGroups",
+// e.g. "2m,15s,5".
+//
+// Returns:
+//   - AdaptiveInterval: The parsed settings.
+//   - error: An error if an interval or the number of groups is invalid, nil otherwise.
+func ParseAdaptiveInterval(spec string) (AdaptiveInterval, error) {
+	parts := strings.Split(spec, ",")
+	if len(parts) != 3 {
+		return AdaptiveInterval{}, fmt.Errorf("adaptive polling settings %q must be \"far,near,nearGroups\"", spec)
+	}
+
+	far, err := time.ParseDuration(strings.TrimSpace(parts[0]))
+	if err != nil || far <= 0 {
+		return AdaptiveInterval{}, fmt.Errorf("invalid far interval %q", parts[0])
+	}
+	near, err := time.ParseDuration(strings.TrimSpace(parts[1]))
+	if err != nil || near <= 0 || near > far {
+		return AdaptiveInterval{}, fmt.Errorf("invalid near interval %q", parts[1])
+	}
+	nearGroups, err := strconv.Atoi(strings.TrimSpace(parts[2]))
+	if err != nil || nearGroups < 0 {
+		return AdaptiveInterval{}, fmt.Errorf("invalid number of groups %q", parts[2])
+	}
+	return AdaptiveInterval{Far: far, Near: near, NearGroups: nearGroups}, nil
+}
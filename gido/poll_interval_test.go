@@ -0,0 +1,77 @@
+package gido
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAdaptiveInterval(t *testing.T) {
+	adaptive := AdaptiveInterval{Far: 2 * time.Minute, Near: 15 * time.Second, NearGroups: 5}
+	tests := []struct {
+		waitCount int
+		want      time.Duration
+	}{
+		{waitCount: 30, want: 2 * time.Minute},
+		{waitCount: 6, want: 2 * time.Minute},
+		{waitCount: 5, want: 15 * time.Second},
+		{waitCount: 1, want: 15 * time.Second},
+	}
+
+	for _, test := range tests {
+		if got := adaptive.interval(test.waitCount); got != test.want {
+			t.Errorf("interval with %d groups remaining %v, want %v", test.waitCount, got, test.want)
+		}
+	}
+}
+
+func TestParseAdaptiveInterval(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    AdaptiveInterval
+		wantErr bool
+	}{
+		{spec: "2m,15s,5", want: AdaptiveInterval{Far: 2 * time.Minute, Near: 15 * time.Second, NearGroups: 5}},
+		{spec: " 1m , 30s , 0 ", want: AdaptiveInterval{Far: time.Minute, Near: 30 * time.Second}},
+		{spec: "2m,15s", wantErr: true},
+		{spec: "15s,2m,5", wantErr: true},
+		{spec: "0s,0s,5", wantErr: true},
+		{spec: "2m,15s,-1", wantErr: true},
+		{spec: "2m,soon,5", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.spec, func(t *testing.T) {
+			got, err := ParseAdaptiveInterval(test.spec)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("got error %v, want error %v", err, test.wantErr)
+			}
+			if got != test.want {
+				t.Fatalf("got %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestTrackerAdaptiveInterval(t *testing.T) {
+	poller := newTestPoller(NewFakeWaitInfoSource(waitInfo(10)))
+	tt := NewTicketTracker(20,
+		WithTrackerPoller(poller),
+		WithTrackerAdaptiveInterval(AdaptiveInterval{Far: 2 * time.Minute, Near: 15 * time.Second, NearGroups: 5}),
+	)
+	tt.subscription = poller.SubscribeEvery(0, func(info WaitInfo, err error) {})
+	defer tt.subscription.Unsubscribe()
+
+	tests := []struct {
+		currentNumber int
+		want          time.Duration
+	}{
+		{currentNumber: 10, want: 2 * time.Minute},
+		{currentNumber: 17, want: 15 * time.Second},
+	}
+	for _, test := range tests {
+		tt.handleUpdate(waitInfo(test.currentNumber), nil)
+		if got := poller.currentInterval(); got != test.want {
+			t.Fatalf("polling every %v with %d groups remaining, want %v", got, 20-test.currentNumber, test.want)
+		}
+	}
+}
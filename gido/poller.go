@@ -0,0 +1,503 @@
+package gido
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultPollInterval is how often a Poller fetches the wait info unless configured otherwise.
+	DefaultPollInterval = 1 * time.Minute
+	// DefaultMinPollInterval is the shortest interval a Poller polls at unless configured otherwise,
+	// whatever interval its subscribers ask for, so the upstream is not hammered.
+	DefaultMinPollInterval = 15 * time.Second
+	// DefaultBreakerThreshold is how many polls in a row must fail before the upstream is considered down.
+	DefaultBreakerThreshold = 3
+	// DefaultBreakerCooldown is how long polling is paused once the upstream is considered down.
+	DefaultBreakerCooldown = 5 * time.Minute
+)
+
+// ErrUpstreamDown is broadcast by a Poller once per outage, when the upstream is considered down.
+var ErrUpstreamDown = errors.New("upstream is unreachable")
+
+// HealthListener is told when the upstream polled by a Poller goes down and when it recovers.
+// err is the failure that opened the circuit breaker, nil on recovery.
+type HealthListener func(healthy bool, err error)
+
+// PollerSubscriber receives every snapshot fetched by a Poller.
+// err is non-nil when the fetch failed, in which case info must be ignored.
+type PollerSubscriber func(info WaitInfo, err error)
+
+// Snapshot is a wait info successfully fetched by a Poller, along with when it was fetched.
+type Snapshot struct {
+	At   time.Time
+	Info WaitInfo
+}
+
+// Poller fetches the wait info from a WaitInfoSource once per interval and fans the
+// snapshot out to the subscribers, so all trackers share a single upstream request.
+// Polling only runs while there is at least one subscriber. Subscribers may ask for their
+// own interval, in which case the poller polls at the shortest one, but never more often
+// than its minimum interval, and hands each subscriber a snapshot once per its own interval.
+// A subscriber asking for a slower interval therefore slows the polling down when alone,
+// and is not flooded by the faster polling of the others. Passive subscribers, see
+// SubscribePassive, receive every snapshot without asking for an interval.
+// The snapshots of the last DefaultRateWindow are kept, see SnapshotBefore.
+//
+// Failed polls are not broadcast until the circuit breaker opens, after breakerThreshold
+// failures in a row. The subscribers then receive a single error wrapping ErrUpstreamDown,
+// and polling is paused for breakerCooldown before trying the upstream again. Nothing more
+// is broadcast until a poll succeeds, so an outage is reported once instead of every poll.
+type Poller struct {
+	source           WaitInfoSource
+	interval         time.Duration
+	minInterval      time.Duration
+	reschedule       chan struct{}
+	rate             *ServiceRateEstimator
+	mu               sync.Mutex
+	nextID           int
+	subscribers      map[int]*subscription
+	healthListeners  map[int]HealthListener
+	cancel           context.CancelFunc
+	history          []Snapshot
+	breakerThreshold int
+	breakerCooldown  time.Duration
+	failures         int
+	down             bool
+	pausedUntil      time.Time
+}
+
+type PollerOption func(*Poller)
+
+// WithPollerInterval sets how often the poller fetches the wait info for the subscribers
+// that do not ask for an interval of their own.
+func WithPollerInterval(interval time.Duration) PollerOption {
+	return func(p *Poller) {
+		p.interval = interval
+	}
+}
+
+// WithPollerMinInterval sets the shortest interval the poller polls at, DefaultMinPollInterval by default.
+func WithPollerMinInterval(minInterval time.Duration) PollerOption {
+	return func(p *Poller) {
+		p.minInterval = minInterval
+	}
+}
+
+// WithPollerBreaker sets after how many failed polls in a row the upstream is considered down,
+// and how long polling is then paused before trying again. A threshold of 0 disables the breaker,
+// broadcasting every failed poll.
+func WithPollerBreaker(threshold int, cooldown time.Duration) PollerOption {
+	return func(p *Poller) {
+		p.breakerThreshold = threshold
+		p.breakerCooldown = cooldown
+	}
+}
+
+// ParseBreakerOption parses circuit breaker settings formatted as "threshold,cooldown", e.g. "3,5m".
+//
+// Returns:
+//   - PollerOption: The WithPollerBreaker option of the settings.
+//   - error: An error if the threshold or the cooldown is invalid, nil otherwise.
+func ParseBreakerOption(spec string) (PollerOption, error) {
+	threshold, cooldown, found := strings.Cut(spec, ",")
+	if !found {
+		return nil, fmt.Errorf("breaker settings %q must be \"threshold,cooldown\"", spec)
+	}
+	thresholdValue, err := strconv.Atoi(strings.TrimSpace(threshold))
+	if err != nil || thresholdValue < 0 {
+		return nil, fmt.Errorf("invalid breaker threshold %q", threshold)
+	}
+	cooldownValue, err := time.ParseDuration(strings.TrimSpace(cooldown))
+	if err != nil || cooldownValue < 0 {
+		return nil, fmt.Errorf("invalid breaker cooldown %q", cooldown)
+	}
+	return WithPollerBreaker(thresholdValue, cooldownValue), nil
+}
+
+// DefaultPoller polls the DefaultWaitInfoSource.
+var DefaultPoller = NewPoller(DefaultWaitInfoSource)
+
+func NewPoller(source WaitInfoSource, opts ...PollerOption) *Poller {
+	p := &Poller{
+		source:           source,
+		interval:         DefaultPollInterval,
+		minInterval:      DefaultMinPollInterval,
+		reschedule:       make(chan struct{}, 1),
+		rate:             NewServiceRateEstimator(DefaultRateWindow),
+		subscribers:      map[int]*subscription{},
+		healthListeners:  map[int]HealthListener{},
+		breakerThreshold: DefaultBreakerThreshold,
+		breakerCooldown:  DefaultBreakerCooldown,
+	}
+
+	// Apply options
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Source returns the WaitInfoSource polled by the poller.
+func (p *Poller) Source() WaitInfoSource {
+	return p.source
+}
+
+// MinInterval returns the shortest interval the poller polls at, whatever its subscribers ask for.
+func (p *Poller) MinInterval() time.Duration {
+	return p.minInterval
+}
+
+// Rate returns the service rate estimator fed with every snapshot fetched by the poller.
+func (p *Poller) Rate() *ServiceRateEstimator {
+	return p.rate
+}
+
+// Fetch fetches the wait info right away, outside of the polling interval, and records it
+// like a polled snapshot. It is meant for on-demand queries such as /wait-info.
+// A successful fetch closes the circuit breaker, but failures are left to the polling.
+// During the cooldown of the circuit breaker, ErrUpstreamDown is returned right away instead
+// of keeping the caller waiting on the retries of an upstream known to be down. Once the
+// cooldown is over, the fetch probes the upstream even if nothing is polling it: a success
+// closes the breaker and a failure starts a new cooldown.
+func (p *Poller) Fetch() (WaitInfo, error) {
+	if p.paused(time.Now()) {
+		return WaitInfo{}, ErrUpstreamDown
+	}
+	probing := !p.Healthy()
+	info, err := p.source.FetchWaitInfo()
+	if err == nil {
+		p.observe(time.Now(), info)
+	}
+	if !isFetchFailure(err) {
+		p.recordSuccess()
+	} else if probing {
+		p.recordFailure(time.Now(), err)
+	}
+	return info, err
+}
+
+// Healthy reports whether the upstream is reachable, i.e. the circuit breaker is closed.
+func (p *Poller) Healthy() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return !p.down
+}
+
+// OnHealthChange registers fn to be told when the upstream goes down and when it recovers.
+//
+// Returns:
+//   - func(): A function removing the listener; calling it more than once is a no-op.
+func (p *Poller) OnHealthChange(fn HealthListener) func() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	id := p.nextID
+	p.nextID++
+	p.healthListeners[id] = fn
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			delete(p.healthListeners, id)
+		})
+	}
+}
+
+// recordSuccess resets the failure count, closing the circuit breaker if it was open.
+func (p *Poller) recordSuccess() {
+	p.mu.Lock()
+	recovered := p.down
+	p.failures, p.down, p.pausedUntil = 0, false, time.Time{}
+	p.mu.Unlock()
+
+	if recovered {
+		log.Printf("Upstream recovered")
+		p.notifyHealth(true, nil)
+	}
+}
+
+// recordFailure counts a failed poll, opening the circuit breaker once the threshold is reached.
+//
+// Returns:
+//   - error: The error to broadcast, which wraps ErrUpstreamDown when the breaker just opened,
+//     or nil if the failure must not be broadcast.
+func (p *Poller) recordFailure(now time.Time, err error) error {
+	p.mu.Lock()
+	if p.breakerThreshold <= 0 {
+		p.mu.Unlock()
+		return err
+	}
+	p.failures++
+	if p.failures < p.breakerThreshold {
+		p.mu.Unlock()
+		return nil
+	}
+	p.pausedUntil = now.Add(p.breakerCooldown)
+	wentDown := !p.down
+	p.down = true
+	p.mu.Unlock()
+
+	if !wentDown {
+		return nil
+	}
+	slog.Warn("Upstream considered down, pausing polling", "failedPolls", p.breakerThreshold, "cooldown", p.breakerCooldown, "error", err)
+	p.notifyHealth(false, err)
+	return fmt.Errorf("%w: %v", ErrUpstreamDown, err)
+}
+
+// paused reports whether polling is paused by the open circuit breaker at now.
+func (p *Poller) paused(now time.Time) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return now.Before(p.pausedUntil)
+}
+
+// notifyHealth tells every health listener about the new health of the upstream.
+func (p *Poller) notifyHealth(healthy bool, err error) {
+	p.mu.Lock()
+	listeners := make([]HealthListener, 0, len(p.healthListeners))
+	for _, fn := range p.healthListeners {
+		listeners = append(listeners, fn)
+	}
+	p.mu.Unlock()
+
+	for _, fn := range listeners {
+		fn(healthy, err)
+	}
+}
+
+// observe feeds a successfully fetched snapshot to the rate estimator and the history.
+func (p *Poller) observe(at time.Time, info WaitInfo) {
+	p.rate.Observe(at, int(info.CurrentNumber))
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.history = append(p.history, Snapshot{At: at, Info: info})
+	cutoff := at.Add(-DefaultRateWindow)
+	idx := 0
+	for idx < len(p.history) && p.history[idx].At.Before(cutoff) {
+		idx++
+	}
+	p.history = p.history[idx:]
+}
+
+// SnapshotBefore returns the latest snapshot fetched at or before t.
+// ok is false if there is none, e.g. because the poller only just started.
+func (p *Poller) SnapshotBefore(t time.Time) (snapshot Snapshot, ok bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for idx := len(p.history) - 1; idx >= 0; idx-- {
+		if !p.history[idx].At.After(t) {
+			return p.history[idx], true
+		}
+	}
+	return Snapshot{}, false
+}
+
+type subscription struct {
+	fn       PollerSubscriber
+	interval time.Duration
+	passive  bool
+	// deliveredAt is when the subscriber last received a snapshot.
+	deliveredAt time.Time
+}
+
+// Subscription is the registration of a subscriber asking for its own polling interval,
+// see SubscribeEvery.
+type Subscription struct {
+	poller *Poller
+	id     int
+	once   sync.Once
+}
+
+// SetInterval changes the interval the subscriber asks for; 0 means the interval of the poller.
+func (sub *Subscription) SetInterval(interval time.Duration) {
+	p := sub.poller
+	p.mu.Lock()
+	if s, exists := p.subscribers[sub.id]; exists {
+		s.interval = interval
+	}
+	p.mu.Unlock()
+	p.signalReschedule()
+}
+
+// Unsubscribe removes the subscription; calling it more than once is a no-op.
+func (sub *Subscription) Unsubscribe() {
+	sub.once.Do(func() { sub.poller.unsubscribe(sub.id) })
+}
+
+// Subscribe registers fn to receive a snapshot once per interval of the poller from now on.
+// Polling starts with the first subscriber and stops after the last one unsubscribes.
+//
+// Returns:
+//   - func(): A function removing the subscription; calling it more than once is a no-op.
+func (p *Poller) Subscribe(fn PollerSubscriber) func() {
+	return p.SubscribeEvery(0, fn).Unsubscribe
+}
+
+// SubscribeEvery registers fn like Subscribe, to receive a snapshot once per interval;
+// 0 means the interval of the poller. The interval can be changed later, e.g. to poll
+// faster as a ticket gets closer. The circuit breaker errors are received right away.
+func (p *Poller) SubscribeEvery(interval time.Duration, fn PollerSubscriber) *Subscription {
+	return p.subscribe(&subscription{fn: fn, interval: interval})
+}
+
+// SubscribePassive registers fn to receive every snapshot fetched for the other subscribers,
+// without asking for an interval of its own, e.g. to record the queue history. While there
+// is no other subscriber, it keeps the poller polling at the interval of the poller.
+//
+// Returns:
+//   - func(): A function removing the subscription; calling it more than once is a no-op.
+func (p *Poller) SubscribePassive(fn PollerSubscriber) func() {
+	return p.subscribe(&subscription{fn: fn, passive: true}).Unsubscribe
+}
+
+func (p *Poller) subscribe(s *subscription) *Subscription {
+	p.mu.Lock()
+	id := p.nextID
+	p.nextID++
+	p.subscribers[id] = s
+
+	if p.cancel == nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		p.cancel = cancel
+		go p.run(ctx)
+	}
+	p.mu.Unlock()
+	p.signalReschedule()
+
+	return &Subscription{poller: p, id: id}
+}
+
+func (p *Poller) unsubscribe(id int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	delete(p.subscribers, id)
+	if len(p.subscribers) == 0 && p.cancel != nil {
+		p.cancel()
+		p.cancel = nil
+	}
+	p.signalReschedule()
+}
+
+// signalReschedule tells the polling loop to recompute its interval, without blocking.
+func (p *Poller) signalReschedule() {
+	select {
+	case p.reschedule <- struct{}{}:
+	default:
+	}
+}
+
+// wantedInterval returns the interval the subscriber asks for. The caller must hold p.mu.
+func (p *Poller) wantedInterval(s *subscription) time.Duration {
+	if s.interval <= 0 {
+		return p.interval
+	}
+	return s.interval
+}
+
+// currentInterval returns the shortest interval asked for by the subscribers, the passive
+// ones excepted, but not shorter than the minimum interval of the poller.
+func (p *Poller) currentInterval() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	interval := time.Duration(0)
+	for _, s := range p.subscribers {
+		if wanted := p.wantedInterval(s); !s.passive && (interval == 0 || wanted < interval) {
+			interval = wanted
+		}
+	}
+	if interval == 0 {
+		interval = p.interval
+	}
+	if interval < p.minInterval {
+		interval = p.minInterval
+	}
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	return interval
+}
+
+func (p *Poller) run(ctx context.Context) {
+	interval := p.currentInterval()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.reschedule:
+			if next := p.currentInterval(); next != interval {
+				interval = next
+				ticker.Reset(interval)
+			}
+
+		case now := <-ticker.C:
+			if p.paused(now) {
+				continue
+			}
+			// the retries of the source are given up once the polling stops
+			info, err := fetchWaitInfo(ctx, p.source)
+			if ctx.Err() != nil {
+				return
+			}
+			if err == nil {
+				p.observe(time.Now(), info)
+			}
+			if isFetchFailure(err) {
+				if err = p.recordFailure(time.Now(), err); err == nil {
+					continue
+				}
+			} else {
+				p.recordSuccess()
+			}
+			p.broadcast(ctx, now, interval, info, err)
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// broadcast hands the snapshot polled at now to the subscribers it is due to: the passive ones,
+// and the others whose interval elapsed since their previous snapshot, give or take half of the
+// polling interval so that a subscriber asking for a multiple of it does not skip a poll.
+// The circuit breaker errors are handed to every subscriber.
+// Subscribers are called outside the lock so they may unsubscribe from within the callback.
+func (p *Poller) broadcast(ctx context.Context, now time.Time, pollInterval time.Duration, info WaitInfo, err error) {
+	p.mu.Lock()
+	subscribers := make([]PollerSubscriber, 0, len(p.subscribers))
+	for _, s := range p.subscribers {
+		due := s.deliveredAt.IsZero() || now.Sub(s.deliveredAt) >= p.wantedInterval(s)-pollInterval/2
+		if s.passive || isFetchFailure(err) || due {
+			s.deliveredAt = now
+			subscribers = append(subscribers, s.fn)
+		}
+	}
+	p.mu.Unlock()
+
+	// the polling may have been stopped while fetching
+	if ctx.Err() != nil {
+		return
+	}
+
+	for _, fn := range subscribers {
+		fn(info, err)
+	}
+}
@@ -0,0 +1,259 @@
+package gido
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestPoller creates a poller of src polling every few milliseconds.
+func newTestPoller(src WaitInfoSource, opts ...PollerOption) *Poller {
+	opts = append([]PollerOption{WithPollerInterval(5 * time.Millisecond), WithPollerMinInterval(0)}, opts...)
+	return NewPoller(src, opts...)
+}
+
+func TestPollerSharesFetches(t *testing.T) {
+	src := NewFakeWaitInfoSource(waitInfo(42))
+	poller := newTestPoller(src)
+
+	first, second := make(chan WaitInfo, 1), make(chan WaitInfo, 1)
+	unsubscribeFirst := poller.Subscribe(func(info WaitInfo, err error) {
+		select {
+		case first <- info:
+		default:
+		}
+	})
+	unsubscribeSecond := poller.Subscribe(func(info WaitInfo, err error) {
+		select {
+		case second <- info:
+		default:
+		}
+	})
+
+	for _, ch := range []chan WaitInfo{first, second} {
+		select {
+		case info := <-ch:
+			if info.CurrentNumber != 42 {
+				t.Fatalf("received current number %v, want 42", info.CurrentNumber)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("subscriber received no snapshot")
+		}
+	}
+	unsubscribeFirst()
+	unsubscribeSecond()
+
+	// polling stops with the last subscriber
+	calls := src.Calls()
+	time.Sleep(50 * time.Millisecond)
+	if src.Calls() > calls+1 {
+		t.Fatalf("poller kept polling after the last subscriber left")
+	}
+}
+
+func TestPollerBreaker(t *testing.T) {
+	src := NewFakeWaitInfoSource()
+	for idx := 0; idx < 3; idx++ {
+		src.PushError(errors.New("connection refused"))
+	}
+	src.Push(waitInfo(42))
+	poller := newTestPoller(src, WithPollerBreaker(3, 20*time.Millisecond))
+
+	health := make(chan bool, 2)
+	removeListener := poller.OnHealthChange(func(healthy bool, err error) { health <- healthy })
+	defer removeListener()
+
+	errs := make(chan error, 10)
+	unsubscribe := poller.Subscribe(func(info WaitInfo, err error) {
+		if err != nil {
+			errs <- err
+		}
+	})
+	defer unsubscribe()
+
+	for _, want := range []bool{false, true} {
+		select {
+		case healthy := <-health:
+			if healthy != want {
+				t.Fatalf("health changed to %v, want %v", healthy, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("health did not change to %v", want)
+		}
+	}
+
+	unsubscribe()
+	close(errs)
+	var received []error
+	for err := range errs {
+		received = append(received, err)
+	}
+	if len(received) != 1 || !errors.Is(received[0], ErrUpstreamDown) {
+		t.Fatalf("received errors %v, want a single ErrUpstreamDown", received)
+	}
+}
+
+func TestPollerClosedQueueIsNotFailure(t *testing.T) {
+	src := NewFakeWaitInfoSource()
+	src.PushError(ErrUpstreamClosed)
+	poller := newTestPoller(src, WithPollerBreaker(1, time.Minute))
+
+	received := make(chan error, 1)
+	unsubscribe := poller.Subscribe(func(info WaitInfo, err error) {
+		select {
+		case received <- err:
+		default:
+		}
+	})
+	defer unsubscribe()
+
+	select {
+	case err := <-received:
+		if !errors.Is(err, ErrUpstreamClosed) {
+			t.Fatalf("received %v, want ErrUpstreamClosed", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("subscriber received nothing")
+	}
+	if !poller.Healthy() {
+		t.Fatalf("a closed queue opened the circuit breaker")
+	}
+}
+
+func TestPollerFetchWhileDown(t *testing.T) {
+	src := NewFakeWaitInfoSource()
+	src.PushError(errors.New("connection refused"))
+	poller := newTestPoller(src, WithPollerBreaker(1, time.Minute))
+
+	down := make(chan struct{})
+	removeListener := poller.OnHealthChange(func(healthy bool, err error) {
+		if !healthy {
+			close(down)
+		}
+	})
+	defer removeListener()
+	unsubscribe := poller.Subscribe(func(info WaitInfo, err error) {})
+	defer unsubscribe()
+
+	select {
+	case <-down:
+	case <-time.After(time.Second):
+		t.Fatalf("upstream not considered down")
+	}
+	calls := src.Calls()
+	if _, err := poller.Fetch(); !errors.Is(err, ErrUpstreamDown) {
+		t.Fatalf("Fetch returned %v, want ErrUpstreamDown", err)
+	}
+	if src.Calls() != calls {
+		t.Fatalf("Fetch queried the upstream while it is down")
+	}
+}
+
+func TestPollerPerSubscriberInterval(t *testing.T) {
+	src := NewFakeWaitInfoSource(waitInfo(42))
+	poller := newTestPoller(src)
+
+	var fastCount, slowCount atomic.Int32
+	fast := poller.SubscribeEvery(5*time.Millisecond, func(info WaitInfo, err error) { fastCount.Add(1) })
+	slow := poller.SubscribeEvery(50*time.Millisecond, func(info WaitInfo, err error) { slowCount.Add(1) })
+	time.Sleep(300 * time.Millisecond)
+	fast.Unsubscribe()
+	slow.Unsubscribe()
+
+	if received := slowCount.Load(); received < 2 || received > 10 {
+		t.Fatalf("slow subscriber received %d snapshots in 300ms, want about 6", received)
+	}
+	if fastCount.Load() < 3*slowCount.Load() {
+		t.Fatalf("fast subscriber received %d snapshots, want many more than the slow one's %d", fastCount.Load(), slowCount.Load())
+	}
+}
+
+func TestPollerPassiveSubscriberFollows(t *testing.T) {
+	src := NewFakeWaitInfoSource(waitInfo(42))
+	poller := newTestPoller(src)
+
+	unsubscribePassive := poller.SubscribePassive(func(info WaitInfo, err error) {})
+	defer unsubscribePassive()
+	subscription := poller.SubscribeEvery(50*time.Millisecond, func(info WaitInfo, err error) {})
+	time.Sleep(300 * time.Millisecond)
+	subscription.Unsubscribe()
+
+	// the passive subscriber does not keep the polling at the 5ms of the poller
+	if calls := src.Calls(); calls > 12 {
+		t.Fatalf("polled %d times in 300ms, want about 6 at the 50ms of the only active subscriber", calls)
+	}
+}
+
+func TestPollerFetchProbesAfterCooldown(t *testing.T) {
+	src := NewFakeWaitInfoSource()
+	src.PushError(errors.New("connection refused"))
+	src.PushError(errors.New("connection refused"))
+	src.Push(waitInfo(42))
+	poller := newTestPoller(src, WithPollerBreaker(1, 30*time.Millisecond))
+
+	health := make(chan bool, 2)
+	removeListener := poller.OnHealthChange(func(healthy bool, err error) { health <- healthy })
+	defer removeListener()
+
+	// the breaker opens, then the last subscriber leaves during the outage
+	unsubscribe := poller.Subscribe(func(info WaitInfo, err error) {})
+	select {
+	case <-health:
+	case <-time.After(time.Second):
+		t.Fatalf("upstream not considered down")
+	}
+	unsubscribe()
+
+	// during the cooldown the upstream is not queried
+	calls := src.Calls()
+	if _, err := poller.Fetch(); !errors.Is(err, ErrUpstreamDown) {
+		t.Fatalf("Fetch during the cooldown returned %v, want ErrUpstreamDown", err)
+	}
+	if src.Calls() != calls {
+		t.Fatalf("Fetch queried the upstream during the cooldown")
+	}
+
+	// a failed probe starts a new cooldown
+	time.Sleep(40 * time.Millisecond)
+	if _, err := poller.Fetch(); err == nil || errors.Is(err, ErrUpstreamDown) {
+		t.Fatalf("probe returned %v, want the failure of the upstream", err)
+	}
+	if _, err := poller.Fetch(); !errors.Is(err, ErrUpstreamDown) {
+		t.Fatalf("Fetch after a failed probe returned %v, want ErrUpstreamDown", err)
+	}
+
+	// a successful probe closes the breaker without any subscriber polling
+	time.Sleep(40 * time.Millisecond)
+	info, err := poller.Fetch()
+	if err != nil || info.CurrentNumber != 42 {
+		t.Fatalf("probe returned %v, %v, want current number 42", info.CurrentNumber, err)
+	}
+	if !poller.Healthy() {
+		t.Fatalf("a successful probe did not close the circuit breaker")
+	}
+	select {
+	case healthy := <-health:
+		if !healthy {
+			t.Fatalf("health changed to down, want recovered")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("recovery not reported")
+	}
+}
+
+func TestPollerMinInterval(t *testing.T) {
+	src := NewFakeWaitInfoSource(waitInfo(42))
+	poller := NewPoller(src, WithPollerInterval(time.Minute), WithPollerMinInterval(50*time.Millisecond))
+
+	subscription := poller.SubscribeEvery(time.Millisecond, func(info WaitInfo, err error) {})
+	if got := poller.currentInterval(); got != 50*time.Millisecond {
+		t.Fatalf("polling every %v, want the minimum interval of 50ms", got)
+	}
+	time.Sleep(300 * time.Millisecond)
+	subscription.Unsubscribe()
+
+	if calls := src.Calls(); calls > 8 {
+		t.Fatalf("polled %d times in 300ms, want about 6 at the minimum interval", calls)
+	}
+}
@@ -0,0 +1,219 @@
+package gido
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// maxSampleGap is the longest gap between two samples still counted as continuous
+// observation when computing the throughput.
+const maxSampleGap = 10 * time.Minute
+
+// Sample is a single polled wait info snapshot of a store.
+type Sample struct {
+	StoreID       string    `json:"store_id"`
+	Timestamp     time.Time `json:"timestamp"`
+	CurrentNumber int       `json:"current_number"`
+	TotalWaiting  int       `json:"total_waiting"`
+	RawData       string    `json:"raw_data"`
+}
+
+// Recorder stores every polled wait info into a local time-series file,
+// one JSON encoded Sample per line.
+type Recorder struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+}
+
+// NewRecorder opens the history file at path for appending, creating it if needed.
+func NewRecorder(path string) (*Recorder, error) {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create history directory: %v", err)
+		}
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history file: %v", err)
+	}
+	return &Recorder{path: path, file: file}, nil
+}
+
+// Record appends a sample to the history file.
+func (r *Recorder) Record(sample Sample) error {
+	data, err := json.Marshal(sample)
+	if err != nil {
+		return fmt.Errorf("failed to encode sample: %v", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, err := r.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write sample: %v", err)
+	}
+	return nil
+}
+
+// Attach records every snapshot answered by the handler of the store as a sample, including
+// the closed and not yet called queues, skipping the failed fetches and malformed responses.
+// The recorder follows the polling of the trackers without changing its interval, but keeps
+// the poller polling at its own interval when no tracker is running, see SubscribePassive.
+//
+// Returns:
+//   - func(): A function detaching the recorder from the poller.
+func (r *Recorder) Attach(storeID string, poller *Poller) func() {
+	return poller.SubscribePassive(func(info WaitInfo, err error) {
+		// the closed and not yet called queues are recorded as well, with -1 for the missing fields
+		if err != nil && !errors.Is(err, ErrUpstreamClosed) && !errors.Is(err, ErrNoTicketYet) {
+			return
+		}
+		sample := Sample{
+			StoreID:       storeID,
+			Timestamp:     time.Now(),
+			CurrentNumber: int(info.CurrentNumber),
+			TotalWaiting:  int(info.TotalWaiting),
+			RawData:       info.RawData,
+		}
+		if err := r.Record(sample); err != nil {
+			slog.Error("Failed to record wait info", "store", storeID, "error", err)
+		}
+	})
+}
+
+// Close closes the history file.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.file.Close()
+}
+
+// Samples reads the samples of the store recorded since the given time, in chronological order.
+// The file is read without the lock of Record, so that reading a long history does not hold back
+// the pollers recording their snapshots; a sample being written is skipped like a truncated one.
+func (r *Recorder) Samples(storeID string, since time.Time) ([]Sample, error) {
+	file, err := os.Open(r.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history file: %v", err)
+	}
+	defer file.Close()
+
+	var samples []Sample
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var sample Sample
+		if err := json.Unmarshal(scanner.Bytes(), &sample); err != nil {
+			// skip lines truncated by a crash
+			continue
+		}
+		if sample.StoreID == storeID && !sample.Timestamp.Before(since) {
+			samples = append(samples, sample)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history file: %v", err)
+	}
+
+	sort.Slice(samples, func(a, b int) bool {
+		return samples[a].Timestamp.Before(samples[b].Timestamp)
+	})
+	return samples, nil
+}
+
+// HourlyStats is the typical queue of a weekday and hour.
+type HourlyStats struct {
+	Weekday time.Weekday
+	Hour    int
+	// Samples is the number of samples in the bucket.
+	Samples int
+	// AvgWaiting is the average number of groups waiting.
+	AvgWaiting float64
+	// Throughput is the average number of groups called per hour, or -1 if unknown.
+	Throughput float64
+}
+
+type hourlyBucket struct {
+	samples      int
+	totalWaiting int
+	served       int
+	observed     time.Duration
+}
+
+// Stats aggregates the recorded samples of the store since the given time by weekday and hour
+// in the given location. Only the buckets with samples are returned, ordered by weekday and hour.
+func (r *Recorder) Stats(storeID string, since time.Time, loc *time.Location) ([]HourlyStats, error) {
+	samples, err := r.Samples(storeID, since)
+	if err != nil {
+		return nil, err
+	}
+
+	buckets := map[[2]int]*hourlyBucket{}
+	bucketOf := func(t time.Time) *hourlyBucket {
+		t = t.In(loc)
+		key := [2]int{int(t.Weekday()), t.Hour()}
+		if buckets[key] == nil {
+			buckets[key] = &hourlyBucket{}
+		}
+		return buckets[key]
+	}
+
+	for idx, sample := range samples {
+		if sample.TotalWaiting < 0 {
+			continue
+		}
+		bucket := bucketOf(sample.Timestamp)
+		bucket.samples++
+		bucket.totalWaiting += sample.TotalWaiting
+
+		// count the groups called since the previous sample, if it is recent enough
+		if idx == 0 {
+			continue
+		}
+		prev := samples[idx-1]
+		gap := sample.Timestamp.Sub(prev.Timestamp)
+		if gap > maxSampleGap || prev.CurrentNumber <= 0 || sample.CurrentNumber < prev.CurrentNumber {
+			continue
+		}
+		bucket.served += sample.CurrentNumber - prev.CurrentNumber
+		bucket.observed += gap
+	}
+
+	stats := make([]HourlyStats, 0, len(buckets))
+	for key, bucket := range buckets {
+		if bucket.samples == 0 {
+			continue
+		}
+		throughput := -1.0
+		if bucket.observed > 0 {
+			throughput = float64(bucket.served) / bucket.observed.Hours()
+		}
+		stats = append(stats, HourlyStats{
+			Weekday:    time.Weekday(key[0]),
+			Hour:       key[1],
+			Samples:    bucket.samples,
+			AvgWaiting: float64(bucket.totalWaiting) / float64(bucket.samples),
+			Throughput: throughput,
+		})
+	}
+	sort.Slice(stats, func(a, b int) bool {
+		if stats[a].Weekday != stats[b].Weekday {
+			return stats[a].Weekday < stats[b].Weekday
+		}
+		return stats[a].Hour < stats[b].Hour
+	})
+	return stats, nil
+}
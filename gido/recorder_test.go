@@ -0,0 +1,62 @@
+package gido
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRecorderStats(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history", "samples.jsonl")
+	r, err := NewRecorder(path)
+	if err != nil {
+		t.Fatalf("NewRecorder failed: %v", err)
+	}
+	defer r.Close()
+
+	// Tuesday the 7th of January 2025
+	at := func(hour, minute int) time.Time {
+		return time.Date(2025, time.January, 7, hour, minute, 0, 0, time.UTC)
+	}
+	samples := []Sample{
+		{StoreID: "gido", Timestamp: at(11, 0), CurrentNumber: 1, TotalWaiting: 99},
+		{StoreID: "gido", Timestamp: at(12, 0), CurrentNumber: 10, TotalWaiting: 10},
+		{StoreID: "other", Timestamp: at(12, 2), CurrentNumber: 500, TotalWaiting: 99},
+		{StoreID: "gido", Timestamp: at(12, 5), CurrentNumber: 13, TotalWaiting: 20},
+		{StoreID: "gido", Timestamp: at(12, 10), CurrentNumber: 15, TotalWaiting: 30},
+		// after a gap longer than maxSampleGap, nothing is counted as called
+		{StoreID: "gido", Timestamp: at(13, 30), CurrentNumber: 100, TotalWaiting: 5},
+		// a closed queue is left out of the averages
+		{StoreID: "gido", Timestamp: at(14, 0), CurrentNumber: -1, TotalWaiting: -1},
+	}
+	for _, sample := range samples {
+		if err := r.Record(sample); err != nil {
+			t.Fatalf("Record failed: %v", err)
+		}
+	}
+	// a sample truncated by a crash is skipped
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatalf("failed to open history file: %v", err)
+	}
+	file.WriteString(`{"store_id":"gido","timest`)
+	file.Close()
+
+	stats, err := r.Stats("gido", at(11, 30), time.UTC)
+	if err != nil {
+		t.Fatalf("Stats failed: %v", err)
+	}
+	want := []HourlyStats{
+		{Weekday: time.Tuesday, Hour: 12, Samples: 3, AvgWaiting: 20, Throughput: 30},
+		{Weekday: time.Tuesday, Hour: 13, Samples: 1, AvgWaiting: 5, Throughput: -1},
+	}
+	if len(stats) != len(want) {
+		t.Fatalf("got %+v, want %+v", stats, want)
+	}
+	for idx := range want {
+		if stats[idx] != want[idx] {
+			t.Fatalf("got %+v, want %+v", stats[idx], want[idx])
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package gido
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// ReplayWaitInfoSource is a WaitInfoSource that replays raw handler responses recorded in a file.
+//
+// The file contains one raw response body per line, e.g. "0|123|45". Empty lines and
+// lines starting with "#" are ignored. Each fetch returns the next response; the last
+// response is repeated once the file has been fully replayed.
+type ReplayWaitInfoSource struct {
+	mu        sync.Mutex
+	responses []string
+	next      int
+}
+
+// NewReplayWaitInfoSource loads the responses to replay from the file at path.
+func NewReplayWaitInfoSource(path string) (*ReplayWaitInfoSource, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open replay file: %v", err)
+	}
+	defer file.Close()
+
+	src := &ReplayWaitInfoSource{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		src.responses = append(src.responses, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read replay file: %v", err)
+	}
+
+	if len(src.responses) == 0 {
+		return nil, fmt.Errorf("replay file %s contains no responses", path)
+	}
+	return src, nil
+}
+
+func (src *ReplayWaitInfoSource) FetchWaitInfo() (WaitInfo, error) {
+	src.mu.Lock()
+	defer src.mu.Unlock()
+
+	response := src.responses[src.next]
+	if src.next < len(src.responses)-1 {
+		src.next++
+	}
+	return parseWaitInfoFromResponse(response)
+}
+
+// ReplaySourceFactory returns a SourceFactory replaying the responses recorded in the file at path.
+// The file is loaded once, and each store replays it from the start with its own cursor.
+func ReplaySourceFactory(path string) (SourceFactory, error) {
+	src, err := NewReplayWaitInfoSource(path)
+	if err != nil {
+		return nil, err
+	}
+	return func(Store) WaitInfoSource {
+		return &ReplayWaitInfoSource{responses: src.responses}
+	}, nil
+}
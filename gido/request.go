@@ -4,30 +4,103 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"time"
 )
 
-// fetchWaitInfo retrieves the wait information for "吉哆火鍋百匯" from the specified URL.
-// It constructs the URL using the current date in YYYYMMDD format and the current timestamp in milliseconds.
-// The function sends an HTTP GET request to the constructed URL and returns the response body as a string.
-// If any error occurs during the process, it returns an error.
+const (
+	// DefaultBaseURL is the WaitInfo_GIDOHandler endpoint used by the official GIDO wait info page.
+	DefaultBaseURL = "http://vpn.weshine.com.tw:8088/WaitInfoWeb/WaitInfo_GIDOHandler.ashx"
+	// DefaultDepCode is the DEP_CODE of "吉哆火鍋百匯".
+	DefaultDepCode = "吉哆火鍋百匯"
+	// DefaultKind is the Kind parameter sent along with DefaultDepCode.
+	DefaultKind = "a1"
+)
+
+// HTTPWaitInfoSource is a WaitInfoSource that fetches the wait info from a WaitInfo_GIDOHandler endpoint.
+type HTTPWaitInfoSource struct {
+	baseURL  string
+	depCode  string
+	kind     string
+	client   *http.Client
+	location *time.Location
+}
+
+type HTTPWaitInfoSourceOption func(*HTTPWaitInfoSource)
+
+// WithBaseURL overrides the handler URL, e.g. to point at a staging or mirror endpoint.
+func WithBaseURL(baseURL string) HTTPWaitInfoSourceOption {
+	return func(src *HTTPWaitInfoSource) {
+		src.baseURL = baseURL
+	}
+}
+
+func WithDepCode(depCode string) HTTPWaitInfoSourceOption {
+	return func(src *HTTPWaitInfoSource) {
+		src.depCode = depCode
+	}
+}
+
+func WithKind(kind string) HTTPWaitInfoSourceOption {
+	return func(src *HTTPWaitInfoSource) {
+		src.kind = kind
+	}
+}
+
+// WithLocation sets the time zone of the date sent to the handler, DefaultLocation by default.
+func WithLocation(location *time.Location) HTTPWaitInfoSourceOption {
+	return func(src *HTTPWaitInfoSource) {
+		src.location = location
+	}
+}
+
+func WithHTTPClient(client *http.Client) HTTPWaitInfoSourceOption {
+	return func(src *HTTPWaitInfoSource) {
+		src.client = client
+	}
+}
+
+// NewHTTPWaitInfoSource creates a WaitInfoSource for "吉哆火鍋百匯" on the official endpoint,
+// using an HTTP client with a timeout of 2 seconds unless overridden by the options.
+func NewHTTPWaitInfoSource(opts ...HTTPWaitInfoSourceOption) *HTTPWaitInfoSource {
+	src := &HTTPWaitInfoSource{
+		baseURL: DefaultBaseURL,
+		depCode: DefaultDepCode,
+		kind:    DefaultKind,
+		client: &http.Client{
+			Timeout: 2 * time.Second,
+		},
+	}
+
+	// Apply options
+	for _, opt := range opts {
+		opt(src)
+	}
+	return src
+}
+
+// FetchWaitInfo retrieves the wait information from the configured endpoint.
+// It constructs the URL using the current date in YYYYMMDD format, in the time zone of the source,
+// and the current timestamp in milliseconds.
+// The function sends an HTTP GET request to the constructed URL and parses the response body.
 //
 // Returns:
-//   - string: The response body from the HTTP GET request.
+//   - WaitInfo: The wait info parsed from the response body.
 //   - error: An error if the HTTP request fails, the status code is not OK, or reading the response body fails.
-func fetchWaitInfo() (WaitInfo, error) {
-	// get current date in YYYYMMDD format
-	currentDate := time.Now().Format("20060102")
+func (src *HTTPWaitInfoSource) FetchWaitInfo() (WaitInfo, error) {
+	// get current date in YYYYMMDD format, in the time zone of the store rather than of the host
+	location := src.location
+	if location == nil {
+		location = DefaultLocation
+	}
+	currentDate := time.Now().In(location).Format("20060102")
 	// get current timestamp in milliseconds
 	timestamp := time.Now().UnixNano() / int64(time.Millisecond)
 	// construct the URL
-	url := fmt.Sprintf("http://vpn.weshine.com.tw:8088/WaitInfoWeb/WaitInfo_GIDOHandler.ashx?act=WaitInfo&DEP_CODE=吉哆火鍋百匯&Kind=a1&date=%s&_=%d", currentDate, timestamp)
+	requestURL := fmt.Sprintf("%s?act=WaitInfo&DEP_CODE=%s&Kind=%s&date=%s&_=%d",
+		src.baseURL, url.QueryEscape(src.depCode), url.QueryEscape(src.kind), currentDate, timestamp)
 
-	// create an HTTP client with a timeout of 2 seconds
-	client := &http.Client{
-		Timeout: 2 * time.Second,
-	}
-	resp, err := client.Get(url)
+	resp, err := src.client.Get(requestURL)
 	if err != nil {
 		return WaitInfo{}, fmt.Errorf("HTTP request failed: %v", err)
 	}
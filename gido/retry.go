@@ -0,0 +1,157 @@
+package gido
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultRetryAttempts is how many times a failed fetch is retried unless configured otherwise.
+	DefaultRetryAttempts = 2
+	// DefaultRetryBaseDelay is the delay before the first retry, doubled after each attempt.
+	DefaultRetryBaseDelay = 500 * time.Millisecond
+	// DefaultRetryMaxDelay caps the delay between two attempts.
+	DefaultRetryMaxDelay = 5 * time.Second
+)
+
+// isFetchFailure reports whether err means the upstream could not be reached or answered
+// something unusable. The closed and not yet called queues are valid answers, not failures.
+func isFetchFailure(err error) bool {
+	return err != nil && !errors.Is(err, ErrUpstreamClosed) && !errors.Is(err, ErrNoTicketYet)
+}
+
+// RetryWaitInfoSource is a WaitInfoSource retrying the failed fetches of another source,
+// waiting a jittered exponential backoff between the attempts.
+type RetryWaitInfoSource struct {
+	source    WaitInfoSource
+	attempts  int
+	baseDelay time.Duration
+	maxDelay  time.Duration
+}
+
+type RetryWaitInfoSourceOption func(*RetryWaitInfoSource)
+
+// WithRetryAttempts sets how many times a failed fetch is retried; 0 disables the retries.
+func WithRetryAttempts(attempts int) RetryWaitInfoSourceOption {
+	return func(src *RetryWaitInfoSource) {
+		src.attempts = attempts
+	}
+}
+
+// WithRetryBackoff sets the delay before the first retry and the cap of the delay between two attempts.
+func WithRetryBackoff(baseDelay, maxDelay time.Duration) RetryWaitInfoSourceOption {
+	return func(src *RetryWaitInfoSource) {
+		src.baseDelay = baseDelay
+		src.maxDelay = maxDelay
+	}
+}
+
+// NewRetryWaitInfoSource wraps source so its failed fetches are retried
+// DefaultRetryAttempts times unless overridden by the options.
+func NewRetryWaitInfoSource(source WaitInfoSource, opts ...RetryWaitInfoSourceOption) *RetryWaitInfoSource {
+	src := &RetryWaitInfoSource{
+		source:    source,
+		attempts:  DefaultRetryAttempts,
+		baseDelay: DefaultRetryBaseDelay,
+		maxDelay:  DefaultRetryMaxDelay,
+	}
+
+	// Apply options
+	for _, opt := range opts {
+		opt(src)
+	}
+	return src
+}
+
+// ParseRetryOptions parses retry settings formatted as "attempts" or "attempts,baseDelay,maxDelay",
+// e.g. "3,1s,10s".
+//
+// Returns:
+//   - []RetryWaitInfoSourceOption: The options to pass to NewRetryWaitInfoSource.
+//   - error: An error if the attempts or a delay is invalid, nil otherwise.
+func ParseRetryOptions(spec string) ([]RetryWaitInfoSourceOption, error) {
+	parts := strings.Split(spec, ",")
+	if len(parts) != 1 && len(parts) != 3 {
+		return nil, fmt.Errorf("retry settings %q must be \"attempts\" or \"attempts,baseDelay,maxDelay\"", spec)
+	}
+
+	attempts, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil || attempts < 0 {
+		return nil, fmt.Errorf("invalid retry attempts %q", parts[0])
+	}
+	opts := []RetryWaitInfoSourceOption{WithRetryAttempts(attempts)}
+	if len(parts) == 1 {
+		return opts, nil
+	}
+
+	baseDelay, err := time.ParseDuration(strings.TrimSpace(parts[1]))
+	if err != nil || baseDelay < 0 {
+		return nil, fmt.Errorf("invalid retry base delay %q", parts[1])
+	}
+	maxDelay, err := time.ParseDuration(strings.TrimSpace(parts[2]))
+	if err != nil || maxDelay < baseDelay {
+		return nil, fmt.Errorf("invalid retry max delay %q", parts[2])
+	}
+	return append(opts, WithRetryBackoff(baseDelay, maxDelay)), nil
+}
+
+// RetrySourceFactory wraps the sources created by newSource into RetryWaitInfoSources.
+func RetrySourceFactory(newSource SourceFactory, opts ...RetryWaitInfoSourceOption) SourceFactory {
+	return func(store Store) WaitInfoSource {
+		return NewRetryWaitInfoSource(newSource(store), opts...)
+	}
+}
+
+// FetchWaitInfo fetches the wait info from the wrapped source, retrying while it fails,
+// see FetchWaitInfoContext.
+func (src *RetryWaitInfoSource) FetchWaitInfo() (WaitInfo, error) {
+	return src.FetchWaitInfoContext(context.Background())
+}
+
+// FetchWaitInfoContext fetches the wait info from the wrapped source, retrying while it fails.
+// The closed and not yet called queues are returned right away. The retries are given up as
+// soon as ctx is done, e.g. when the poller stops, instead of waiting out the backoff.
+//
+// Returns:
+//   - WaitInfo: The wait info of the last attempt.
+//   - error: The error of the last attempt, nil if one of the attempts succeeded.
+func (src *RetryWaitInfoSource) FetchWaitInfoContext(ctx context.Context) (WaitInfo, error) {
+	info, err := fetchWaitInfo(ctx, src.source)
+	for attempt := 0; attempt < src.attempts && isFetchFailure(err); attempt++ {
+		delay := src.backoff(attempt)
+		slog.Warn("Fetching the wait info failed, retrying", "delay", delay, "error", err)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return info, err
+		}
+		info, err = fetchWaitInfo(ctx, src.source)
+	}
+	return info, err
+}
+
+// backoff returns the delay before the given retry: the base delay doubled per attempt and
+// capped to the max delay, of which a random half is dropped so that the retries of several
+// sources hitting the same outage do not line up.
+func (src *RetryWaitInfoSource) backoff(attempt int) time.Duration {
+	delay := src.baseDelay
+	for idx := 0; idx < attempt && delay < src.maxDelay; idx++ {
+		delay *= 2
+	}
+	if delay > src.maxDelay {
+		delay = src.maxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return delay/2 + rand.N(delay/2+1)
+}
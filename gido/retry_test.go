@@ -0,0 +1,57 @@
+package gido
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetryWaitInfoSource(t *testing.T) {
+	src := NewFakeWaitInfoSource()
+	src.PushError(errors.New("connection refused"))
+	src.PushError(errors.New("connection refused"))
+	src.Push(waitInfo(42))
+	retry := NewRetryWaitInfoSource(src, WithRetryAttempts(2), WithRetryBackoff(time.Millisecond, time.Millisecond))
+
+	info, err := retry.FetchWaitInfo()
+	if err != nil || info.CurrentNumber != 42 {
+		t.Fatalf("got %v, %v, want 42 after two retries", info.CurrentNumber, err)
+	}
+	if src.Calls() != 3 {
+		t.Fatalf("fetched %d times, want 3", src.Calls())
+	}
+}
+
+func TestRetryWaitInfoSourceClosedQueue(t *testing.T) {
+	src := NewFakeWaitInfoSource()
+	src.PushError(ErrUpstreamClosed)
+	retry := NewRetryWaitInfoSource(src, WithRetryBackoff(time.Millisecond, time.Millisecond))
+
+	if _, err := retry.FetchWaitInfo(); !errors.Is(err, ErrUpstreamClosed) {
+		t.Fatalf("got %v, want ErrUpstreamClosed", err)
+	}
+	if src.Calls() != 1 {
+		t.Fatalf("a closed queue was retried")
+	}
+}
+
+func TestRetryWaitInfoSourceCancelled(t *testing.T) {
+	src := NewFakeWaitInfoSource()
+	src.PushError(errors.New("connection refused"))
+	retry := NewRetryWaitInfoSource(src, WithRetryAttempts(3), WithRetryBackoff(time.Minute, time.Minute))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	start := time.Now()
+	if _, err := retry.FetchWaitInfoContext(ctx); err == nil {
+		t.Fatalf("got no error, want the error of the first attempt")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("gave up the retries after %v, want right after the cancellation", elapsed)
+	}
+	if src.Calls() != 1 {
+		t.Fatalf("fetched %d times after the cancellation, want 1", src.Calls())
+	}
+}
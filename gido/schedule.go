@@ -0,0 +1,200 @@
+package gido
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultLocation is the time zone of the stores unless their schedule sets one: the zone of
+// their opening hours, of the date sent to the handler and of the day rollover of the numbering.
+// Taiwan has no daylight saving time, so a fixed zone does not need the tzdata of the host.
+var DefaultLocation = time.FixedZone("Asia/Taipei", 8*60*60)
+
+// maxScheduleLookahead is how far ahead NextOpen and NextClose look for a session.
+const maxScheduleLookahead = 14
+
+// weekdayNames maps the names accepted in Schedule.ClosedDays to their weekday.
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// Session is an opening session of a store, e.g. lunch, from Open to Close formatted as "HH:MM".
+// A Close at or before Open ends on the next day, e.g. "17:00" to "01:00".
+type Session struct {
+	Open  string `json:"open" yaml:"open"`
+	Close string `json:"close" yaml:"close"`
+}
+
+// Schedule is the opening hours of a store. A nil schedule is always open.
+type Schedule struct {
+	// TimeZone is the IANA name of the time zone of the sessions, DefaultLocation if empty.
+	TimeZone string `json:"timezone,omitempty" yaml:"timezone,omitempty"`
+	// Sessions are the opening sessions of every day the store is open.
+	Sessions []Session `json:"sessions" yaml:"sessions"`
+	// ClosedDays are the days without any session, either weekdays such as "mon" or dates such as "2025-01-28".
+	ClosedDays []string `json:"closed_days,omitempty" yaml:"closed_days,omitempty"`
+
+	location       *time.Location
+	offsets        [][2]time.Duration
+	closedWeekdays map[time.Weekday]bool
+	closedDates    map[string]bool
+}
+
+// UnmarshalJSON decodes and validates a schedule, e.g.
+//
+//	{"sessions": [{"open": "11:00", "close": "14:30"}, {"open": "17:00", "close": "21:30"}], "closed_days": ["mon"]}
+func (s *Schedule) UnmarshalJSON(data []byte) error {
+	type rawSchedule Schedule
+	var raw rawSchedule
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*s = Schedule(raw)
+	return s.compile()
+}
+
+// UnmarshalYAML decodes and validates a schedule written in YAML, like UnmarshalJSON.
+func (s *Schedule) UnmarshalYAML(unmarshal func(any) error) error {
+	type rawSchedule Schedule
+	var raw rawSchedule
+	if err := unmarshal(&raw); err != nil {
+		return err
+	}
+	*s = Schedule(raw)
+	return s.compile()
+}
+
+// compile validates the schedule and prepares the sessions and closed days for lookups.
+func (s *Schedule) compile() error {
+	// DefaultLocation is looked up on use, as it may be configured after the schedule is loaded
+	s.location = nil
+	if s.TimeZone != "" {
+		location, err := time.LoadLocation(s.TimeZone)
+		if err != nil {
+			return fmt.Errorf("invalid time zone %q: %v", s.TimeZone, err)
+		}
+		s.location = location
+	}
+
+	if len(s.Sessions) == 0 {
+		return fmt.Errorf("opening hours must have at least one session")
+	}
+	s.offsets = nil
+	for _, session := range s.Sessions {
+		open, err := parseClock(session.Open)
+		if err != nil {
+			return err
+		}
+		closeOffset, err := parseClock(session.Close)
+		if err != nil {
+			return err
+		}
+		if closeOffset <= open {
+			closeOffset += 24 * time.Hour
+		}
+		s.offsets = append(s.offsets, [2]time.Duration{open, closeOffset})
+	}
+
+	s.closedWeekdays = map[time.Weekday]bool{}
+	s.closedDates = map[string]bool{}
+	for _, day := range s.ClosedDays {
+		day = strings.ToLower(strings.TrimSpace(day))
+		if weekday, ok := weekdayNames[day]; ok {
+			s.closedWeekdays[weekday] = true
+			continue
+		}
+		if _, err := time.Parse(time.DateOnly, day); err != nil {
+			return fmt.Errorf("invalid closed day %q, expected a weekday such as \"mon\" or a date such as \"2025-01-28\"", day)
+		}
+		s.closedDates[day] = true
+	}
+	return nil
+}
+
+// parseClock parses a time of the day formatted as "HH:MM" into the duration since midnight.
+// "24:00" is accepted as the end of the day.
+func parseClock(value string) (time.Duration, error) {
+	var hours, minutes int
+	if _, err := fmt.Sscanf(strings.TrimSpace(value), "%d:%d", &hours, &minutes); err != nil ||
+		hours < 0 || minutes < 0 || minutes > 59 || hours*60+minutes > 24*60 {
+		return 0, fmt.Errorf("invalid time %q, expected \"HH:MM\"", value)
+	}
+	return time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute, nil
+}
+
+// sessions returns the sessions opening on the days from the day before t to maxScheduleLookahead
+// days after it, in chronological order. The sessions of the closed days are skipped.
+func (s *Schedule) sessions(t time.Time) [][2]time.Time {
+	location := s.Location()
+	t = t.In(location)
+
+	var sessions [][2]time.Time
+	for day := -1; day <= maxScheduleLookahead; day++ {
+		midnight := time.Date(t.Year(), t.Month(), t.Day()+day, 0, 0, 0, 0, location)
+		if s.closedWeekdays[midnight.Weekday()] || s.closedDates[midnight.Format(time.DateOnly)] {
+			continue
+		}
+		for _, offset := range s.offsets {
+			sessions = append(sessions, [2]time.Time{midnight.Add(offset[0]), midnight.Add(offset[1])})
+		}
+	}
+	return sessions
+}
+
+// Location returns the time zone of the schedule, DefaultLocation for a nil schedule.
+func (s *Schedule) Location() *time.Location {
+	if s == nil || s.location == nil {
+		return DefaultLocation
+	}
+	return s.location
+}
+
+// IsOpen reports whether the store is open at t. A nil schedule is always open.
+func (s *Schedule) IsOpen(t time.Time) bool {
+	if s == nil {
+		return true
+	}
+	for _, session := range s.sessions(t) {
+		if !t.Before(session[0]) && t.Before(session[1]) {
+			return true
+		}
+	}
+	return false
+}
+
+// NextOpen returns when the next session after t starts.
+// ok is false for a nil schedule, or if no session starts within the next two weeks.
+func (s *Schedule) NextOpen(t time.Time) (openAt time.Time, ok bool) {
+	if s == nil {
+		return time.Time{}, false
+	}
+	for _, session := range s.sessions(t) {
+		if session[0].After(t) && (!ok || session[0].Before(openAt)) {
+			openAt, ok = session[0], true
+		}
+	}
+	return openAt, ok
+}
+
+// NextClose returns when the session open at t ends, or when the next session ends if the
+// store is closed at t, e.g. for a ticket taken before opening.
+// ok is false for a nil schedule, or if no session ends within the next two weeks.
+func (s *Schedule) NextClose(t time.Time) (closeAt time.Time, ok bool) {
+	if s == nil {
+		return time.Time{}, false
+	}
+	for _, session := range s.sessions(t) {
+		if session[1].After(t) && (!ok || session[1].Before(closeAt)) {
+			closeAt, ok = session[1], true
+		}
+	}
+	return closeAt, ok
+}
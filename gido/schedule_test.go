@@ -0,0 +1,92 @@
+package gido
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// mustSchedule decodes a schedule written in JSON.
+func mustSchedule(t *testing.T, data string) *Schedule {
+	t.Helper()
+
+	var schedule Schedule
+	if err := json.Unmarshal([]byte(data), &schedule); err != nil {
+		t.Fatalf("invalid schedule %s: %v", data, err)
+	}
+	return &schedule
+}
+
+// at returns the given time on the given day of January 2025 in the DefaultLocation.
+// The 6th is a Monday.
+func at(day, hour, minute int) time.Time {
+	return time.Date(2025, time.January, day, hour, minute, 0, 0, DefaultLocation)
+}
+
+const (
+	twoSessions = `{"sessions": [{"open": "11:00", "close": "14:30"}, {"open": "17:00", "close": "21:30"}], "closed_days": ["mon", "2025-01-08"]}`
+	lateNight   = `{"sessions": [{"open": "17:00", "close": "01:00"}]}`
+)
+
+func TestScheduleIsOpen(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule string
+		at       time.Time
+		want     bool
+	}{
+		{name: "during a session", schedule: twoSessions, at: at(7, 12, 0), want: true},
+		{name: "at opening", schedule: twoSessions, at: at(7, 11, 0), want: true},
+		{name: "at closing", schedule: twoSessions, at: at(7, 14, 30), want: false},
+		{name: "between sessions", schedule: twoSessions, at: at(7, 15, 0), want: false},
+		{name: "closed weekday", schedule: twoSessions, at: at(6, 12, 0), want: false},
+		{name: "closed date", schedule: twoSessions, at: at(8, 12, 0), want: false},
+		{name: "session crossing midnight, before midnight", schedule: lateNight, at: at(7, 23, 0), want: true},
+		{name: "session crossing midnight, after midnight", schedule: lateNight, at: at(8, 0, 30), want: true},
+		{name: "after a session crossing midnight", schedule: lateNight, at: at(8, 1, 30), want: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := mustSchedule(t, test.schedule).IsOpen(test.at); got != test.want {
+				t.Fatalf("open %v, want %v", got, test.want)
+			}
+		})
+	}
+
+	var always *Schedule
+	if !always.IsOpen(at(7, 3, 0)) {
+		t.Fatalf("a nil schedule is not always open")
+	}
+}
+
+func TestScheduleNextClose(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule string
+		at       time.Time
+		want     time.Time
+	}{
+		{name: "during a session", schedule: twoSessions, at: at(7, 12, 0), want: at(7, 14, 30)},
+		{name: "before opening", schedule: twoSessions, at: at(7, 9, 0), want: at(7, 14, 30)},
+		{name: "between sessions", schedule: twoSessions, at: at(7, 15, 0), want: at(7, 21, 30)},
+		{name: "after the last session of the day", schedule: twoSessions, at: at(7, 22, 0), want: at(9, 14, 30)},
+		{name: "after the last session before a closed day", schedule: twoSessions, at: at(5, 22, 0), want: at(7, 14, 30)},
+		{name: "session crossing midnight, before midnight", schedule: lateNight, at: at(7, 23, 0), want: at(8, 1, 0)},
+		{name: "session crossing midnight, after midnight", schedule: lateNight, at: at(8, 0, 30), want: at(8, 1, 0)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			closeAt, ok := mustSchedule(t, test.schedule).NextClose(test.at)
+			if !ok || !closeAt.Equal(test.want) {
+				t.Fatalf("next close %v (ok %v), want %v", closeAt, ok, test.want)
+			}
+		})
+	}
+
+	var always *Schedule
+	if _, ok := always.NextClose(at(7, 12, 0)); ok {
+		t.Fatalf("a nil schedule closes")
+	}
+}
@@ -0,0 +1,93 @@
+package gido
+
+import (
+	"context"
+	"sync"
+)
+
+// WaitInfoSource provides the current wait info of a GIDO queue.
+// Implementations must be safe for concurrent use.
+type WaitInfoSource interface {
+	FetchWaitInfo() (WaitInfo, error)
+}
+
+// ContextWaitInfoSource is a WaitInfoSource whose fetches can be given up through a context,
+// e.g. a RetryWaitInfoSource waiting between two attempts.
+type ContextWaitInfoSource interface {
+	WaitInfoSource
+	FetchWaitInfoContext(ctx context.Context) (WaitInfo, error)
+}
+
+// fetchWaitInfo fetches the wait info from source, given up when ctx is done if the source
+// is a ContextWaitInfoSource.
+func fetchWaitInfo(ctx context.Context, source WaitInfoSource) (WaitInfo, error) {
+	if contextSource, ok := source.(ContextWaitInfoSource); ok {
+		return contextSource.FetchWaitInfoContext(ctx)
+	}
+	return source.FetchWaitInfo()
+}
+
+// DefaultWaitInfoSource is the source used when no other source is injected.
+var DefaultWaitInfoSource WaitInfoSource = NewHTTPWaitInfoSource()
+
+type fakeResponse struct {
+	info WaitInfo
+	err  error
+}
+
+// FakeWaitInfoSource is an in-memory WaitInfoSource for offline testing.
+// Queued responses are returned in order; the last one is repeated once the queue is drained.
+type FakeWaitInfoSource struct {
+	mu        sync.Mutex
+	responses []fakeResponse
+	calls     int
+}
+
+// NewFakeWaitInfoSource creates a FakeWaitInfoSource with the given responses queued.
+func NewFakeWaitInfoSource(infos ...WaitInfo) *FakeWaitInfoSource {
+	src := &FakeWaitInfoSource{}
+	for _, info := range infos {
+		src.Push(info)
+	}
+	return src
+}
+
+// Push queues a wait info to be returned by a later fetch.
+func (src *FakeWaitInfoSource) Push(info WaitInfo) {
+	src.mu.Lock()
+	defer src.mu.Unlock()
+
+	src.responses = append(src.responses, fakeResponse{info: info})
+}
+
+// PushError queues an error to be returned by a later fetch.
+func (src *FakeWaitInfoSource) PushError(err error) {
+	src.mu.Lock()
+	defer src.mu.Unlock()
+
+	src.responses = append(src.responses, fakeResponse{err: err})
+}
+
+// Calls returns how many times FetchWaitInfo has been called.
+func (src *FakeWaitInfoSource) Calls() int {
+	src.mu.Lock()
+	defer src.mu.Unlock()
+
+	return src.calls
+}
+
+func (src *FakeWaitInfoSource) FetchWaitInfo() (WaitInfo, error) {
+	src.mu.Lock()
+	defer src.mu.Unlock()
+
+	src.calls++
+	if len(src.responses) == 0 {
+		return WaitInfo{CurrentNumber: -1, TotalWaiting: -1}, nil
+	}
+
+	resp := src.responses[0]
+	if len(src.responses) > 1 {
+		src.responses = src.responses[1:]
+	}
+	return resp.info, resp.err
+}
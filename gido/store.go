@@ -0,0 +1,172 @@
+package gido
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Store describes a GIDO branch served by the WaitInfo_GIDOHandler backend.
+type Store struct {
+	// ID is the short identifier used in commands, e.g. "gido".
+	ID string `json:"id" yaml:"id"`
+	// Name is the display name, e.g. "吉哆火鍋百匯".
+	Name string `json:"name" yaml:"name"`
+	// DepCode is the DEP_CODE parameter sent to the handler.
+	DepCode string `json:"dep_code" yaml:"dep_code"`
+	// Kind is the Kind parameter sent to the handler.
+	Kind string `json:"kind" yaml:"kind"`
+	// Hours is the opening hours of the store, nil if it is always open.
+	Hours *Schedule `json:"hours,omitempty" yaml:"hours,omitempty"`
+}
+
+// DefaultStore is the store the bot has always been following.
+var DefaultStore = Store{
+	ID:      "gido",
+	Name:    "吉哆火鍋百匯",
+	DepCode: DefaultDepCode,
+	Kind:    DefaultKind,
+}
+
+// SourceFactory creates the WaitInfoSource of a store.
+type SourceFactory func(store Store) WaitInfoSource
+
+// HTTPSourceFactory returns a SourceFactory creating an HTTPWaitInfoSource for the DEP_CODE,
+// Kind and time zone of each store. The options are applied before the store parameters.
+func HTTPSourceFactory(opts ...HTTPWaitInfoSourceOption) SourceFactory {
+	return func(store Store) WaitInfoSource {
+		storeOpts := append(opts[:len(opts):len(opts)], WithDepCode(store.DepCode), WithKind(store.Kind), WithLocation(store.Hours.Location()))
+		return NewHTTPWaitInfoSource(storeOpts...)
+	}
+}
+
+type registeredStore struct {
+	store  Store
+	poller *Poller
+}
+
+// StoreRegistry holds the stores the bot can follow, each with its own shared Poller.
+// The first registered store is the default one.
+type StoreRegistry struct {
+	mu         sync.RWMutex
+	newSource  SourceFactory
+	pollerOpts []PollerOption
+	stores     []*registeredStore
+}
+
+// NewStoreRegistry creates a registry whose stores are polled from the sources created by newSource.
+func NewStoreRegistry(newSource SourceFactory, pollerOpts ...PollerOption) *StoreRegistry {
+	return &StoreRegistry{
+		newSource:  newSource,
+		pollerOpts: pollerOpts,
+	}
+}
+
+// Register adds a store to the registry.
+// It returns an error if the store is incomplete or its ID is already registered.
+func (r *StoreRegistry) Register(store Store) error {
+	if store.ID == "" || store.DepCode == "" {
+		return fmt.Errorf("store must have an id and a dep_code")
+	}
+	if strings.ContainsAny(store.ID, ": ") {
+		return fmt.Errorf("store id %q must not contain colons or spaces", store.ID)
+	}
+	if store.Name == "" {
+		store.Name = store.DepCode
+	}
+	if store.Kind == "" {
+		store.Kind = DefaultKind
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, rs := range r.stores {
+		if rs.store.ID == store.ID {
+			return fmt.Errorf("store %q already registered", store.ID)
+		}
+	}
+
+	r.stores = append(r.stores, &registeredStore{
+		store:  store,
+		poller: NewPoller(r.newSource(store), r.pollerOpts...),
+	})
+	return nil
+}
+
+func (r *StoreRegistry) find(id string) *registeredStore {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.stores) == 0 {
+		return nil
+	}
+	if id == "" {
+		return r.stores[0]
+	}
+	for _, rs := range r.stores {
+		if rs.store.ID == id {
+			return rs
+		}
+	}
+	return nil
+}
+
+// Get returns the store with the given ID, or the default store if id is empty.
+func (r *StoreRegistry) Get(id string) (Store, error) {
+	rs := r.find(id)
+	if rs == nil {
+		return Store{}, fmt.Errorf("unknown store %q", id)
+	}
+	return rs.store, nil
+}
+
+// Poller returns the shared poller of the store with the given ID, or of the default store if id is empty.
+func (r *StoreRegistry) Poller(id string) (*Poller, error) {
+	rs := r.find(id)
+	if rs == nil {
+		return nil, fmt.Errorf("unknown store %q", id)
+	}
+	return rs.poller, nil
+}
+
+// List returns all registered stores in registration order.
+func (r *StoreRegistry) List() []Store {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stores := make([]Store, 0, len(r.stores))
+	for _, rs := range r.stores {
+		stores = append(stores, rs.store)
+	}
+	return stores
+}
+
+// Search returns the stores whose ID or name contains query, ignoring case.
+func (r *StoreRegistry) Search(query string) []Store {
+	query = strings.ToLower(query)
+
+	var matches []Store
+	for _, store := range r.List() {
+		if strings.Contains(strings.ToLower(store.ID), query) || strings.Contains(strings.ToLower(store.Name), query) {
+			matches = append(matches, store)
+		}
+	}
+	return matches
+}
+
+// LoadStores reads a JSON array of stores from the file at path.
+func LoadStores(path string) ([]Store, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stores file: %v", err)
+	}
+
+	var stores []Store
+	if err := json.Unmarshal(data, &stores); err != nil {
+		return nil, fmt.Errorf("failed to parse stores file: %v", err)
+	}
+	return stores, nil
+}
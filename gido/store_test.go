@@ -0,0 +1,115 @@
+package gido
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStoreRegistryRegister(t *testing.T) {
+	tests := []struct {
+		name    string
+		store   Store
+		wantErr bool
+	}{
+		{name: "complete", store: Store{ID: "taipei", DepCode: "taipei"}},
+		{name: "duplicate id", store: Store{ID: "gido", DepCode: "other"}, wantErr: true},
+		{name: "missing id", store: Store{DepCode: "taipei"}, wantErr: true},
+		{name: "missing dep_code", store: Store{ID: "taipei"}, wantErr: true},
+		{name: "id with a colon", store: Store{ID: "gido:taipei", DepCode: "taipei"}, wantErr: true},
+		{name: "id with a space", store: Store{ID: "gido taipei", DepCode: "taipei"}, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			registry := NewStoreRegistry(func(Store) WaitInfoSource { return NewFakeWaitInfoSource() })
+			if err := registry.Register(DefaultStore); err != nil {
+				t.Fatalf("failed to register the default store: %v", err)
+			}
+
+			err := registry.Register(test.store)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("error %v, want error %v", err, test.wantErr)
+			}
+			if test.wantErr && len(registry.List()) != 1 {
+				t.Fatalf("rejected store was registered: %v", registry.List())
+			}
+		})
+	}
+}
+
+func TestStoreRegistryDefaults(t *testing.T) {
+	registry := NewStoreRegistry(func(Store) WaitInfoSource { return NewFakeWaitInfoSource() })
+	if err := registry.Register(Store{ID: "taipei", DepCode: "台北店"}); err != nil {
+		t.Fatalf("failed to register store: %v", err)
+	}
+
+	store, err := registry.Get("")
+	if err != nil {
+		t.Fatalf("no default store: %v", err)
+	}
+	if store.ID != "taipei" || store.Name != "台北店" || store.Kind != DefaultKind {
+		t.Fatalf("store %+v, want the dep_code as name and the default kind", store)
+	}
+	if _, err := registry.Get("unknown"); err == nil {
+		t.Fatalf("unknown store found")
+	}
+}
+
+func TestStoreRegistrySourcePerStore(t *testing.T) {
+	sources := map[string]*FakeWaitInfoSource{}
+	registry := NewStoreRegistry(func(store Store) WaitInfoSource {
+		if sources[store.ID] != nil {
+			t.Fatalf("source of store %s created twice", store.ID)
+		}
+		sources[store.ID] = NewFakeWaitInfoSource(waitInfo(len(sources) + 1))
+		return sources[store.ID]
+	})
+	for _, id := range []string{"gido", "taipei"} {
+		if err := registry.Register(Store{ID: id, DepCode: id}); err != nil {
+			t.Fatalf("failed to register store %s: %v", id, err)
+		}
+	}
+
+	first, _ := registry.Poller("gido")
+	second, _ := registry.Poller("taipei")
+	if first == second {
+		t.Fatalf("stores share a poller")
+	}
+	if info, _ := first.Source().FetchWaitInfo(); info.CurrentNumber != 1 {
+		t.Fatalf("gido fetched current number %v, want 1", info.CurrentNumber)
+	}
+	if info, _ := second.Source().FetchWaitInfo(); info.CurrentNumber != 2 {
+		t.Fatalf("taipei fetched current number %v, want 2", info.CurrentNumber)
+	}
+	if sources["gido"].Calls() != 1 || sources["taipei"].Calls() != 1 {
+		t.Fatalf("fetches were not sent to the source of their store")
+	}
+}
+
+func TestReplaySourceFactory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "replay.txt")
+	if err := os.WriteFile(path, []byte("# recorded\n0|10|5\n\n0|11|4\n"), 0o644); err != nil {
+		t.Fatalf("failed to write replay file: %v", err)
+	}
+
+	newSource, err := ReplaySourceFactory(path)
+	if err != nil {
+		t.Fatalf("failed to load replay file: %v", err)
+	}
+	first, second := newSource(Store{ID: "gido"}), newSource(Store{ID: "taipei"})
+
+	// each store replays the file from the start, whatever the other stores fetched
+	for _, want := range []int{10, 11, 11} {
+		if info, _ := first.FetchWaitInfo(); info.CurrentNumber != WaitInfoIntField(want) {
+			t.Fatalf("first store replayed %v, want %v", info.CurrentNumber, want)
+		}
+	}
+	if info, _ := second.FetchWaitInfo(); info.CurrentNumber != 10 {
+		t.Fatalf("second store replayed %v, want 10", info.CurrentNumber)
+	}
+
+	if _, err := ReplaySourceFactory(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Fatalf("missing replay file loaded")
+	}
+}
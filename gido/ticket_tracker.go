@@ -2,23 +2,182 @@ package gido
 
 import (
 	"context"
+	"fmt"
+	"sync"
 	"time"
 )
 
+// TrackerStatus is the state of the tracked ticket observed in the latest snapshot.
+type TrackerStatus struct {
+	// CurrentNumber is the number currently being called.
+	CurrentNumber WaitInfoIntField
+	// WaitCount is the number of groups before the tracked ticket.
+	WaitCount int
+	// ETA is the estimated time until the tracked ticket is called, valid only if HasETA is true.
+	ETA    time.Duration
+	HasETA bool
+	// UpdatedAt is when the snapshot was received.
+	UpdatedAt time.Time
+	// Called is the number of groups called since the previous reported update.
+	Called int
+}
+
+// ThresholdKind tells what a Threshold is measured in.
+type ThresholdKind int
+
+const (
+	// ThresholdGroups fires when at most Value groups remain before the tracked ticket.
+	ThresholdGroups ThresholdKind = iota
+	// ThresholdMinutes fires when the ETA of the tracked ticket is at most Value minutes.
+	ThresholdMinutes
+)
+
+// Threshold is an alert point fired once while a ticket gets closer to being called.
+type Threshold struct {
+	Kind  ThresholdKind
+	Value int
+}
+
+// reached reports whether the status is at or past the threshold.
+func (th Threshold) reached(status TrackerStatus) bool {
+	switch th.Kind {
+	case ThresholdGroups:
+		return status.WaitCount <= th.Value
+	case ThresholdMinutes:
+		return status.HasETA && status.ETA <= time.Duration(th.Value)*time.Minute
+	}
+	return false
+}
+
+// StopReason tells why a TicketTracker stopped.
+type StopReason int
+
+const (
+	// StopRequested means Stop was called, e.g. by the user.
+	StopRequested StopReason = iota
+	// StopCompleted means the tracked ticket was reached or passed.
+	StopCompleted
+	// StopClosed means the opening session of the store ended before the ticket was reached.
+	StopClosed
+	// StopExpired means the tracker ran longer than its max duration.
+	StopExpired
+	// StopReset means the numbering was reset and the tracker is set to stop on resets, see ResetStop.
+	StopReset
+)
+
+// resetTolerance is how far the current number may go back before it is considered a reset of
+// the numbering, so that small corrections made by the staff are not.
+const resetTolerance = 5
+
+// ResetKind tells how a reset of the numbering was detected.
+type ResetKind int
+
+const (
+	// ResetNumberDrop means the current number dropped sharply, e.g. between lunch and dinner.
+	ResetNumberDrop ResetKind = iota
+	// ResetNewDay means the date changed since the previous snapshot, in the time zone of the store,
+	// and the current number went back, even by less than the tolerance of ResetNumberDrop.
+	ResetNewDay
+)
+
+// Reset describes a reset of the numbering detected by a TicketTracker.
+type Reset struct {
+	Kind ResetKind
+	// Previous and Current are the current numbers before and after the reset.
+	Previous WaitInfoIntField
+	Current  WaitInfoIntField
+}
+
+// ResetAction tells what a TicketTracker does when the numbering is reset.
+type ResetAction int
+
+const (
+	// ResetRearm keeps tracking the same ticket number in the new numbering,
+	// re-arming the thresholds and reporting the next update.
+	ResetRearm ResetAction = iota
+	// ResetStop stops the tracker with StopReset, as the ticket belongs to the previous numbering.
+	ResetStop
+)
+
+var resetActionNames = map[ResetAction]string{
+	ResetRearm: "rearm",
+	ResetStop:  "stop",
+}
+
+// ParseResetAction returns the ResetAction named "rearm" or "stop".
+// An empty name is ResetRearm.
+func ParseResetAction(name string) (ResetAction, error) {
+	if name == "" {
+		return ResetRearm, nil
+	}
+	for action, actionName := range resetActionNames {
+		if actionName == name {
+			return action, nil
+		}
+	}
+	return ResetRearm, fmt.Errorf("unknown reset action %q", name)
+}
+
 type TicketTracker struct {
 	ctx                        context.Context
 	cancel                     context.CancelFunc
+	mu                         sync.Mutex
 	trackingTicketId           int
+	store                      Store
+	poller                     *Poller
 	onStart                    func(ticketID int)
 	onStop                     func(ticketID int)
 	onFetchError               func(err error)
 	onFetchInvalidTicketNumber func()
-	onMonitorUpdate            func(currentNumber string, waitCount int)
+	onMonitorUpdate            func(status TrackerStatus)
 	onTrackComplete            func()
+	onThreshold                func(threshold Threshold, status TrackerStatus)
+	onReset                    func(reset Reset)
+	thresholds                 []Threshold
+	firedThresholds            map[Threshold]bool
+	notifyPolicy               NotifyPolicy
+	lastNotified               *TrackerStatus
+	snoozedUntil               time.Time
+	startedAt                  time.Time
+	latestStatus               *TrackerStatus
+	fetchFailed                bool
+	maxDuration                time.Duration
+	resetAction                ResetAction
+	lastNumber                 int
+	lastDate                   string
+	interval                   time.Duration
+	adaptiveInterval           *AdaptiveInterval
+	subscription               *Subscription
+	stopReason                 StopReason
+	stopped                    bool
 }
 
 type TicketTrackerOption func(*TicketTracker)
 
+// WithTrackerPoller sets the shared Poller the tracker subscribes to.
+// The DefaultPoller is used when this option is not given.
+func WithTrackerPoller(poller *Poller) TicketTrackerOption {
+	return func(tt *TicketTracker) {
+		tt.poller = poller
+	}
+}
+
+// WithTrackerStore records which store the tracker follows.
+// It does not change where the wait info is polled from; combine it with WithTrackerPoller.
+func WithTrackerStore(store Store) TicketTrackerOption {
+	return func(tt *TicketTracker) {
+		tt.store = store
+	}
+}
+
+// WithTrackerSource makes the tracker poll the given WaitInfoSource through a poller of its own.
+// Prefer WithTrackerPoller when several trackers watch the same source.
+func WithTrackerSource(source WaitInfoSource) TicketTrackerOption {
+	return func(tt *TicketTracker) {
+		tt.poller = NewPoller(source)
+	}
+}
+
 func WithTrackerOnStart(fn func(ticketID int)) TicketTrackerOption {
 	return func(tt *TicketTracker) {
 		tt.onStart = fn
@@ -31,18 +190,23 @@ func WithTrackerOnStop(fn func(ticketID int)) TicketTrackerOption {
 	}
 }
 
+// WithTrackerOnFetchError sets the callback fired when the wait info cannot be fetched.
+// With the circuit breaker of the poller, it fires once per outage with an error wrapping
+// ErrUpstreamDown, and the next successful update is always reported to onMonitorUpdate.
 func WithTrackerOnFetchError(fn func(err error)) TicketTrackerOption {
 	return func(tt *TicketTracker) {
 		tt.onFetchError = fn
 	}
 }
 
-func WithTrackerOnMonitorUpdate(fn func(currentNumber string, waitCount int)) TicketTrackerOption {
+func WithTrackerOnMonitorUpdate(fn func(status TrackerStatus)) TicketTrackerOption {
 	return func(tt *TicketTracker) {
 		tt.onMonitorUpdate = fn
 	}
 }
 
+// WithTrackerOnFetchInvalidTicketNumber sets the callback fired when the handler answers without
+// a current number: no ticket has been called yet, or the queue is closed, see ErrUpstreamClosed.
 func WithTrackerOnFetchInvalidTicketNumber(fn func()) TicketTrackerOption {
 	return func(tt *TicketTracker) {
 		tt.onFetchInvalidTicketNumber = fn
@@ -55,6 +219,79 @@ func WithTrackerOnTrackComplete(fn func()) TicketTrackerOption {
 	}
 }
 
+// WithTrackerThresholds sets the thresholds at which onThreshold fires.
+func WithTrackerThresholds(thresholds ...Threshold) TicketTrackerOption {
+	return func(tt *TicketTracker) {
+		tt.thresholds = append(tt.thresholds, thresholds...)
+	}
+}
+
+// WithTrackerOnThreshold sets the callback fired exactly once per threshold.
+// When several thresholds of the same kind are reached by one update, e.g. right after the
+// tracker starts, only the closest one fires and the others are skipped.
+func WithTrackerOnThreshold(fn func(threshold Threshold, status TrackerStatus)) TicketTrackerOption {
+	return func(tt *TicketTracker) {
+		tt.onThreshold = fn
+	}
+}
+
+// WithTrackerNotifyPolicy sets which monitor updates are reported to onMonitorUpdate.
+// By default an update is reported only when the current number advances.
+func WithTrackerNotifyPolicy(policy NotifyPolicy) TicketTrackerOption {
+	return func(tt *TicketTracker) {
+		tt.notifyPolicy = policy
+	}
+}
+
+// WithTrackerStartedAt sets when the tracking started, e.g. to keep the original start time
+// of a tracker restored after a restart. By default it is the time Start is called.
+func WithTrackerStartedAt(startedAt time.Time) TicketTrackerOption {
+	return func(tt *TicketTracker) {
+		tt.startedAt = startedAt
+	}
+}
+
+// WithTrackerMaxDuration stops the tracker with StopExpired once it has been running for d
+// since it started, see WithTrackerStartedAt. By default the tracker has no max duration.
+func WithTrackerMaxDuration(d time.Duration) TicketTrackerOption {
+	return func(tt *TicketTracker) {
+		tt.maxDuration = d
+	}
+}
+
+// WithTrackerInterval sets how often the tracker asks its poller for the wait info.
+// By default the interval of the poller is used. The poller never polls more often than
+// its minimum interval, see WithPollerMinInterval.
+func WithTrackerInterval(interval time.Duration) TicketTrackerOption {
+	return func(tt *TicketTracker) {
+		tt.interval = interval
+	}
+}
+
+// WithTrackerAdaptiveInterval makes the tracker poll at the interval of adaptive matching the
+// groups remaining before its ticket, once it is known. Until then, the interval set by
+// WithTrackerInterval is used.
+func WithTrackerAdaptiveInterval(adaptive AdaptiveInterval) TicketTrackerOption {
+	return func(tt *TicketTracker) {
+		tt.adaptiveInterval = &adaptive
+	}
+}
+
+// WithTrackerResetAction sets what the tracker does when the numbering is reset, ResetRearm by default.
+func WithTrackerResetAction(action ResetAction) TicketTrackerOption {
+	return func(tt *TicketTracker) {
+		tt.resetAction = action
+	}
+}
+
+// WithTrackerOnReset sets the callback fired when a reset of the numbering is detected,
+// before the tracker is re-armed or stopped according to its ResetAction.
+func WithTrackerOnReset(fn func(reset Reset)) TicketTrackerOption {
+	return func(tt *TicketTracker) {
+		tt.onReset = fn
+	}
+}
+
 func NewTicketTracker(ticketID int, opts ...TicketTrackerOption) *TicketTracker {
 	ctx, cancel := context.WithCancel(context.Background())
 
@@ -62,12 +299,17 @@ func NewTicketTracker(ticketID int, opts ...TicketTrackerOption) *TicketTracker
 		ctx:                        ctx,
 		cancel:                     cancel,
 		trackingTicketId:           ticketID,
+		store:                      DefaultStore,
+		poller:                     DefaultPoller,
 		onStart:                    func(ticketID int) {},
 		onStop:                     func(ticketID int) {},
 		onFetchError:               func(err error) {},
 		onFetchInvalidTicketNumber: func() {},
-		onMonitorUpdate:            func(currentNumber string, waitCount int) {},
+		onMonitorUpdate:            func(status TrackerStatus) {},
 		onTrackComplete:            func() {},
+		onThreshold:                func(threshold Threshold, status TrackerStatus) {},
+		onReset:                    func(reset Reset) {},
+		firedThresholds:            map[Threshold]bool{},
 	}
 
 	// Apply options
@@ -77,47 +319,61 @@ func NewTicketTracker(ticketID int, opts ...TicketTrackerOption) *TicketTracker
 	return tt
 }
 
+// Start starts tracking in a goroutine until the ticket is reached, Stop is called, the opening
+// session of the store during which the tracking started ends, or the max duration is exceeded.
 func (tt *TicketTracker) Start() {
+	tt.mu.Lock()
+	if tt.startedAt.IsZero() {
+		tt.startedAt = time.Now()
+	}
+	tt.mu.Unlock()
+	deadline, deadlineReason, hasDeadline := tt.deadline()
+
 	go func() {
 		// Ensure that the onStop is called when the goroutine exits
 		defer func() {
-			tt.onStop(tt.trackingTicketId)
+			tt.onStop(tt.GetTrackingTicketId())
 		}()
 
+		// Receive the snapshots of the shared poller, keeping only the latest one
+		// if the tracker falls behind so the poller is never blocked.
+		updates := make(chan pollResult, 1)
+		subscription := tt.poller.SubscribeEvery(tt.interval, func(info WaitInfo, err error) {
+			for {
+				select {
+				case updates <- pollResult{info: info, err: err}:
+					return
+				default:
+					select {
+					case <-updates:
+					default:
+					}
+				}
+			}
+		})
+		defer subscription.Unsubscribe()
+		tt.mu.Lock()
+		tt.subscription = subscription
+		tt.mu.Unlock()
+
+		// Stop the tracking automatically at the deadline, if any
+		var expired <-chan time.Time
+		if hasDeadline {
+			timer := time.NewTimer(time.Until(deadline))
+			defer timer.Stop()
+			expired = timer.C
+		}
+
 		// if onStart is set, call it with the target ticket number
-		tt.onStart(tt.trackingTicketId)
+		tt.onStart(tt.GetTrackingTicketId())
 
 		for {
 			select {
-			case <-time.After(1 * time.Minute):
-				// Fetch the current wait info
-				currentWaitInfo, err := fetchWaitInfo()
-				if err != nil {
-					tt.onFetchError(err)
-					continue
-				}
-				//
-				if !currentWaitInfo.validateCurrentTicketNumber() {
-					tt.onFetchInvalidTicketNumber()
-					continue
-				}
+			case update := <-updates:
+				tt.handleUpdate(update.info, update.err)
 
-				currentNumber := int(currentWaitInfo.CurrentNumber)
-
-				// Calculate the wait count
-				waitCount := tt.trackingTicketId - currentNumber
-				// If the wait count is greater than zero, it means the ticket is still waiting
-				if waitCount > 0 {
-					tt.onMonitorUpdate(
-						WaitInfoIntField(currentNumber).String(),
-						waitCount,
-					)
-					continue
-				}
-				// If the wait count is less than or equal to zero, it means the ticket has been reached or exceeded
-				tt.onTrackComplete()
-				// Call the Stop method to terminate the tracking
-				tt.Stop()
+			case <-expired:
+				tt.stop(deadlineReason)
 
 			case <-tt.ctx.Done():
 				// Context is cancelled, exit the goroutine
@@ -127,13 +383,246 @@ func (tt *TicketTracker) Start() {
 	}()
 }
 
+// deadline returns when the tracking must stop automatically and why: the end of the opening
+// session during which the tracking started, or the end of the max duration, whichever comes first.
+// ok is false if the store is always open and there is no max duration.
+func (tt *TicketTracker) deadline() (deadline time.Time, reason StopReason, ok bool) {
+	startedAt := tt.GetStartedAt()
+	if closeAt, open := tt.store.Hours.NextClose(startedAt); open {
+		deadline, reason, ok = closeAt, StopClosed, true
+	}
+	if tt.maxDuration > 0 {
+		if expiresAt := startedAt.Add(tt.maxDuration); !ok || expiresAt.Before(deadline) {
+			deadline, reason, ok = expiresAt, StopExpired, true
+		}
+	}
+	return deadline, reason, ok
+}
+
+type pollResult struct {
+	info WaitInfo
+	err  error
+}
+
+// handleUpdate compares a wait info snapshot against the tracked ticket and fires the matching callback.
+func (tt *TicketTracker) handleUpdate(currentWaitInfo WaitInfo, err error) {
+	// The closed and not yet called queues are valid answers, reported as an invalid number below
+	if isFetchFailure(err) {
+		tt.mu.Lock()
+		tt.fetchFailed = true
+		tt.mu.Unlock()
+		tt.onFetchError(err)
+		return
+	}
+	// No ticket has been called yet, or the handler answered a placeholder, e.g. outside the opening hours
+	if !currentWaitInfo.validateCurrentTicketNumber() {
+		tt.onFetchInvalidTicketNumber()
+		return
+	}
+
+	currentNumber := int(currentWaitInfo.CurrentNumber)
+	if stopped := tt.handleReset(currentNumber, time.Now()); stopped {
+		return
+	}
+
+	tt.mu.Lock()
+	// Calculate the wait count
+	waitCount := tt.trackingTicketId - currentNumber
+	// If the wait count is less than or equal to zero, it means the ticket has been reached or exceeded
+	if waitCount <= 0 {
+		tt.mu.Unlock()
+		tt.onTrackComplete()
+		// Terminate the tracking
+		tt.stop(StopCompleted)
+		return
+	}
+
+	// The ticket is still waiting, poll faster or slower according to how far it is
+	var interval time.Duration
+	if tt.adaptiveInterval != nil {
+		interval = tt.adaptiveInterval.interval(waitCount)
+	}
+	subscription := tt.subscription
+	eta, hasETA := tt.poller.Rate().EstimateWait(waitCount)
+	status := TrackerStatus{
+		CurrentNumber: WaitInfoIntField(currentNumber),
+		WaitCount:     waitCount,
+		ETA:           eta,
+		HasETA:        hasETA,
+		UpdatedAt:     time.Now(),
+	}
+	tt.latestStatus = &status
+	if tt.lastNotified != nil && status.CurrentNumber > tt.lastNotified.CurrentNumber {
+		status.Called = int(status.CurrentNumber - tt.lastNotified.CurrentNumber)
+	}
+	// the first update after a failure is always reported, replacing the error shown to the user
+	notify := tt.fetchFailed || tt.notifyPolicy.shouldNotify(status, tt.lastNotified)
+	tt.fetchFailed = false
+	if notify {
+		tt.lastNotified = &status
+	}
+	// Threshold alerts are held back while snoozed, and fire once the snooze is over
+	var reached []Threshold
+	if !status.UpdatedAt.Before(tt.snoozedUntil) {
+		reached = tt.reachThresholds(status)
+	}
+	tt.mu.Unlock()
+
+	if interval > 0 && subscription != nil {
+		subscription.SetInterval(interval)
+	}
+	if notify {
+		tt.onMonitorUpdate(status)
+	}
+	for _, threshold := range reached {
+		tt.onThreshold(threshold, status)
+	}
+}
+
+// handleReset detects a reset of the numbering since the previous valid snapshot, fires
+// onReset and re-arms or stops the tracker accordingly. A ticket compared against the wrong
+// numbering would otherwise be reported as passed too early, or never.
+//
+// Returns:
+//   - bool: true if the tracker was stopped.
+func (tt *TicketTracker) handleReset(currentNumber int, at time.Time) bool {
+	date := at.In(tt.store.Hours.Location()).Format(time.DateOnly)
+
+	tt.mu.Lock()
+	reset := Reset{Previous: WaitInfoIntField(tt.lastNumber), Current: WaitInfoIntField(currentNumber)}
+	isReset := false
+	if tt.lastNumber > 0 {
+		switch {
+		case currentNumber < tt.lastNumber-resetTolerance:
+			reset.Kind, isReset = ResetNumberDrop, true
+		case date != tt.lastDate && currentNumber < tt.lastNumber:
+			reset.Kind, isReset = ResetNewDay, true
+		}
+	}
+	tt.lastNumber, tt.lastDate = currentNumber, date
+	if isReset && tt.resetAction == ResetRearm {
+		tt.firedThresholds = map[Threshold]bool{}
+		tt.lastNotified = nil
+	}
+	tt.mu.Unlock()
+
+	if !isReset {
+		return false
+	}
+	tt.onReset(reset)
+	if tt.resetAction == ResetStop {
+		tt.stop(StopReset)
+		return true
+	}
+	return false
+}
+
+// reachThresholds returns the closest newly reached threshold of each kind,
+// marking every reached threshold as fired. The caller must hold tt.mu.
+func (tt *TicketTracker) reachThresholds(status TrackerStatus) []Threshold {
+	closest := map[ThresholdKind]Threshold{}
+	for _, threshold := range tt.thresholds {
+		if tt.firedThresholds[threshold] || !threshold.reached(status) {
+			continue
+		}
+		tt.firedThresholds[threshold] = true
+		if current, exists := closest[threshold.Kind]; !exists || threshold.Value < current.Value {
+			closest[threshold.Kind] = threshold
+		}
+	}
+
+	var reached []Threshold
+	for _, kind := range []ThresholdKind{ThresholdGroups, ThresholdMinutes} {
+		if threshold, exists := closest[kind]; exists {
+			reached = append(reached, threshold)
+		}
+	}
+	return reached
+}
+
 // Stop gracefully terminates the ticket tracking process.
 // It cancels the context used by the tracker, which signals any running goroutines to exit.
 func (tt *TicketTracker) Stop() {
+	tt.stop(StopRequested)
+}
+
+// stop records why the tracking stopped, unless it already stopped, and cancels the context.
+func (tt *TicketTracker) stop(reason StopReason) {
+	tt.mu.Lock()
+	if !tt.stopped {
+		tt.stopped, tt.stopReason = true, reason
+	}
+	tt.mu.Unlock()
+
 	// Cancel the context to stop the goroutine
 	tt.cancel()
 }
 
+// GetStopReason returns why the tracking stopped, StopRequested if it is still running.
+func (tt *TicketTracker) GetStopReason() StopReason {
+	tt.mu.Lock()
+	defer tt.mu.Unlock()
+
+	return tt.stopReason
+}
+
 func (tt *TicketTracker) GetTrackingTicketId() int {
+	tt.mu.Lock()
+	defer tt.mu.Unlock()
+
 	return tt.trackingTicketId
 }
+
+// SetTrackingTicketId changes the tracked ticket number, e.g. after the user took a new ticket.
+// The thresholds are re-armed and the next update is always reported.
+func (tt *TicketTracker) SetTrackingTicketId(ticketID int) {
+	tt.mu.Lock()
+	defer tt.mu.Unlock()
+
+	tt.trackingTicketId = ticketID
+	tt.firedThresholds = map[Threshold]bool{}
+	tt.lastNotified = nil
+	tt.latestStatus = nil
+}
+
+// Snooze holds back the threshold alerts for the given duration.
+// The completion of the tracking is never snoozed.
+func (tt *TicketTracker) Snooze(d time.Duration) {
+	tt.mu.Lock()
+	defer tt.mu.Unlock()
+
+	tt.snoozedUntil = time.Now().Add(d)
+}
+
+// GetSnoozedUntil returns until when the threshold alerts are snoozed.
+func (tt *TicketTracker) GetSnoozedUntil() time.Time {
+	tt.mu.Lock()
+	defer tt.mu.Unlock()
+
+	return tt.snoozedUntil
+}
+
+// GetStartedAt returns when the tracking started.
+func (tt *TicketTracker) GetStartedAt() time.Time {
+	tt.mu.Lock()
+	defer tt.mu.Unlock()
+
+	return tt.startedAt
+}
+
+// GetLatestStatus returns the status observed in the latest snapshot, whether it was reported or not.
+// ok is false until the first valid snapshot has been received.
+func (tt *TicketTracker) GetLatestStatus() (status TrackerStatus, ok bool) {
+	tt.mu.Lock()
+	defer tt.mu.Unlock()
+
+	if tt.latestStatus == nil {
+		return TrackerStatus{}, false
+	}
+	return *tt.latestStatus, true
+}
+
+// GetStore returns the store the tracker follows.
+func (tt *TicketTracker) GetStore() Store {
+	return tt.store
+}
@@ -0,0 +1,292 @@
+package gido
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// feed hands the next n responses of src to the tracker, as its poller would.
+func feed(tt *TicketTracker, src *FakeWaitInfoSource, n int) {
+	for idx := 0; idx < n; idx++ {
+		info, err := src.FetchWaitInfo()
+		tt.handleUpdate(info, err)
+	}
+}
+
+func waitInfo(currentNumber int) WaitInfo {
+	return WaitInfo{CurrentNumber: WaitInfoIntField(currentNumber), TotalWaiting: 10}
+}
+
+func TestTrackerThresholdCrossing(t *testing.T) {
+	src := NewFakeWaitInfoSource(waitInfo(5), waitInfo(12), waitInfo(13), waitInfo(16), waitInfo(18))
+
+	var fired []int
+	tt := NewTicketTracker(20,
+		WithTrackerSource(src),
+		WithTrackerThresholds(
+			Threshold{Kind: ThresholdGroups, Value: 10},
+			Threshold{Kind: ThresholdGroups, Value: 5},
+			Threshold{Kind: ThresholdGroups, Value: 2},
+		),
+		WithTrackerOnThreshold(func(threshold Threshold, status TrackerStatus) {
+			fired = append(fired, threshold.Value)
+		}),
+	)
+	feed(tt, src, 6)
+
+	want := []int{10, 5, 2}
+	if len(fired) != len(want) {
+		t.Fatalf("fired thresholds %v, want %v", fired, want)
+	}
+	for idx := range want {
+		if fired[idx] != want[idx] {
+			t.Fatalf("fired thresholds %v, want %v", fired, want)
+		}
+	}
+}
+
+func TestTrackerThresholdsSkippedAtStart(t *testing.T) {
+	src := NewFakeWaitInfoSource(waitInfo(17))
+
+	var fired []int
+	tt := NewTicketTracker(20,
+		WithTrackerSource(src),
+		WithTrackerThresholds(
+			Threshold{Kind: ThresholdGroups, Value: 10},
+			Threshold{Kind: ThresholdGroups, Value: 5},
+		),
+		WithTrackerOnThreshold(func(threshold Threshold, status TrackerStatus) {
+			fired = append(fired, threshold.Value)
+		}),
+	)
+	feed(tt, src, 2)
+
+	if len(fired) != 1 || fired[0] != 5 {
+		t.Fatalf("fired thresholds %v, want only the closest one [5]", fired)
+	}
+}
+
+func TestTrackerCompletion(t *testing.T) {
+	src := NewFakeWaitInfoSource(waitInfo(18), waitInfo(21))
+
+	completed := 0
+	tt := NewTicketTracker(20,
+		WithTrackerSource(src),
+		WithTrackerOnTrackComplete(func() { completed++ }),
+	)
+	feed(tt, src, 1)
+	if completed != 0 {
+		t.Fatalf("completed before the ticket was reached")
+	}
+	feed(tt, src, 1)
+
+	if completed != 1 {
+		t.Fatalf("completed %d times, want 1", completed)
+	}
+	if reason := tt.GetStopReason(); reason != StopCompleted {
+		t.Fatalf("stop reason %v, want StopCompleted", reason)
+	}
+}
+
+func TestTrackerCompletionThroughPoller(t *testing.T) {
+	src := NewFakeWaitInfoSource(waitInfo(18), waitInfo(19), waitInfo(20))
+	poller := NewPoller(src, WithPollerInterval(5*time.Millisecond), WithPollerMinInterval(0))
+
+	stopped := make(chan struct{})
+	tt := NewTicketTracker(20,
+		WithTrackerPoller(poller),
+		WithTrackerOnStop(func(ticketID int) { close(stopped) }),
+	)
+	tt.Start()
+
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		tt.Stop()
+		t.Fatalf("tracker did not stop once the ticket was reached")
+	}
+	if reason := tt.GetStopReason(); reason != StopCompleted {
+		t.Fatalf("stop reason %v, want StopCompleted", reason)
+	}
+}
+
+func TestTrackerResetDetection(t *testing.T) {
+	tests := []struct {
+		name        string
+		numbers     []int
+		action      ResetAction
+		wantResets  int
+		wantStopped bool
+	}{
+		{name: "small correction", numbers: []int{50, 47, 52}, action: ResetStop},
+		{name: "number drop rearms", numbers: []int{50, 3, 4}, action: ResetRearm, wantResets: 1},
+		{name: "number drop stops", numbers: []int{50, 3}, action: ResetStop, wantResets: 1, wantStopped: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			src := NewFakeWaitInfoSource()
+			for _, number := range test.numbers {
+				src.Push(waitInfo(number))
+			}
+
+			var resets []Reset
+			tt := NewTicketTracker(100,
+				WithTrackerSource(src),
+				WithTrackerResetAction(test.action),
+				WithTrackerOnReset(func(reset Reset) { resets = append(resets, reset) }),
+			)
+			feed(tt, src, len(test.numbers))
+
+			if len(resets) != test.wantResets {
+				t.Fatalf("detected %d resets, want %d", len(resets), test.wantResets)
+			}
+			if test.wantResets > 0 && (resets[0].Kind != ResetNumberDrop || resets[0].Previous != 50 || resets[0].Current != 3) {
+				t.Fatalf("reset %+v, want a number drop from 50 to 3", resets[0])
+			}
+			if stopped := tt.ctx.Err() != nil; stopped != test.wantStopped {
+				t.Fatalf("stopped %v, want %v", stopped, test.wantStopped)
+			}
+			if test.wantStopped && tt.GetStopReason() != StopReset {
+				t.Fatalf("stop reason %v, want StopReset", tt.GetStopReason())
+			}
+		})
+	}
+}
+
+func TestTrackerResetRearmsThresholds(t *testing.T) {
+	src := NewFakeWaitInfoSource(waitInfo(95), waitInfo(3), waitInfo(96))
+
+	fired := 0
+	tt := NewTicketTracker(100,
+		WithTrackerSource(src),
+		WithTrackerThresholds(Threshold{Kind: ThresholdGroups, Value: 5}),
+		WithTrackerOnThreshold(func(threshold Threshold, status TrackerStatus) { fired++ }),
+	)
+	feed(tt, src, 3)
+
+	if fired != 2 {
+		t.Fatalf("threshold fired %d times, want once per numbering", fired)
+	}
+}
+
+func TestTrackerFetchError(t *testing.T) {
+	src := NewFakeWaitInfoSource()
+	src.PushError(errors.New("connection refused"))
+	src.Push(waitInfo(10))
+
+	var fetchErrors []error
+	updates := 0
+	tt := NewTicketTracker(20,
+		WithTrackerSource(src),
+		WithTrackerNotifyPolicy(NotifyPolicy{Mode: NotifyOnChange}),
+		WithTrackerOnFetchError(func(err error) { fetchErrors = append(fetchErrors, err) }),
+		WithTrackerOnMonitorUpdate(func(status TrackerStatus) { updates++ }),
+	)
+	feed(tt, src, 3)
+
+	if len(fetchErrors) != 1 {
+		t.Fatalf("got %d fetch errors, want 1", len(fetchErrors))
+	}
+	if updates != 1 {
+		t.Fatalf("got %d updates, want 1 for the unchanged number", updates)
+	}
+}
+
+func TestTrackerClosedQueue(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{name: "closed", err: ErrUpstreamClosed},
+		{name: "no ticket yet", err: ErrNoTicketYet},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			src := NewFakeWaitInfoSource()
+			src.PushError(test.err)
+
+			invalid, fetchErrors := 0, 0
+			tt := NewTicketTracker(20,
+				WithTrackerSource(src),
+				WithTrackerOnFetchInvalidTicketNumber(func() { invalid++ }),
+				WithTrackerOnFetchError(func(err error) { fetchErrors++ }),
+			)
+			feed(tt, src, 1)
+
+			if invalid != 1 || fetchErrors != 0 {
+				t.Fatalf("got %d invalid numbers and %d fetch errors, want only an invalid number", invalid, fetchErrors)
+			}
+		})
+	}
+}
+
+func TestTrackerDeadline(t *testing.T) {
+	lunch := `{"sessions": [{"open": "11:00", "close": "14:30"}]}`
+	tests := []struct {
+		name        string
+		hours       string
+		startedAt   time.Time
+		maxDuration time.Duration
+		wantOK      bool
+		want        time.Time
+		wantReason  StopReason
+	}{
+		{name: "always open without max duration", startedAt: at(7, 12, 0)},
+		{name: "close of the session", hours: lunch, startedAt: at(7, 12, 0), wantOK: true, want: at(7, 14, 30), wantReason: StopClosed},
+		{name: "started before opening", hours: lunch, startedAt: at(7, 9, 0), wantOK: true, want: at(7, 14, 30), wantReason: StopClosed},
+		{name: "max duration before the close", hours: lunch, startedAt: at(7, 12, 0), maxDuration: time.Hour, wantOK: true, want: at(7, 13, 0), wantReason: StopExpired},
+		{name: "close before the max duration", hours: lunch, startedAt: at(7, 12, 0), maxDuration: 5 * time.Hour, wantOK: true, want: at(7, 14, 30), wantReason: StopClosed},
+		{name: "max duration of an always open store", startedAt: at(7, 12, 0), maxDuration: time.Hour, wantOK: true, want: at(7, 13, 0), wantReason: StopExpired},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := Store{ID: "test"}
+			if test.hours != "" {
+				store.Hours = mustSchedule(t, test.hours)
+			}
+			tt := NewTicketTracker(20,
+				WithTrackerStore(store),
+				WithTrackerStartedAt(test.startedAt),
+				WithTrackerMaxDuration(test.maxDuration),
+			)
+
+			deadline, reason, ok := tt.deadline()
+			if ok != test.wantOK {
+				t.Fatalf("has deadline %v, want %v", ok, test.wantOK)
+			}
+			if ok && (!deadline.Equal(test.want) || reason != test.wantReason) {
+				t.Fatalf("deadline %v with reason %v, want %v with reason %v", deadline, reason, test.want, test.wantReason)
+			}
+		})
+	}
+}
+
+func TestTrackerStopsAtClose(t *testing.T) {
+	// the tracking started yesterday, during a session that is over by now
+	yesterday := time.Now().In(DefaultLocation).AddDate(0, 0, -1)
+	startedAt := time.Date(yesterday.Year(), yesterday.Month(), yesterday.Day(), 12, 0, 0, 0, DefaultLocation)
+	store := Store{ID: "test", Hours: mustSchedule(t, `{"sessions": [{"open": "11:00", "close": "14:30"}]}`)}
+
+	stopped := make(chan struct{})
+	tt := NewTicketTracker(20,
+		WithTrackerSource(NewFakeWaitInfoSource(waitInfo(5))),
+		WithTrackerStore(store),
+		WithTrackerStartedAt(startedAt),
+		WithTrackerOnStop(func(ticketID int) { close(stopped) }),
+	)
+	tt.Start()
+
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		tt.Stop()
+		t.Fatalf("tracker did not stop at the close of the session")
+	}
+	if reason := tt.GetStopReason(); reason != StopClosed {
+		t.Fatalf("stop reason %v, want StopClosed", reason)
+	}
+}
@@ -1,11 +1,23 @@
 package gido
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
 )
 
+var (
+	// ErrUpstreamClosed is returned when the handler answers but serves no queue,
+	// e.g. an empty body or placeholders in every field outside business hours.
+	ErrUpstreamClosed = errors.New("upstream queue is closed")
+	// ErrMalformed is returned when the response is not in the expected "header|current|waiting" format,
+	// e.g. an HTML error page.
+	ErrMalformed = errors.New("malformed wait info response")
+	// ErrNoTicketYet is returned when groups are waiting but no ticket has been called yet.
+	ErrNoTicketYet = errors.New("no ticket called yet")
+)
+
 type WaitInfoIntField int
 
 func (i WaitInfoIntField) String() string {
@@ -19,8 +31,11 @@ func (i WaitInfoIntField) String() string {
 // WaitInfo represents the information about the current waiting status.
 // It includes the current ticket number, which can be either an integer or a string,
 // and the count of tickets that are currently waiting.
+// Fields the handler answered with a placeholder such as "----" are -1.
 type WaitInfo struct {
-	RawData       string
+	RawData string
+	// Header is the first field of the response, whose meaning is not documented by the handler.
+	Header        string
 	CurrentNumber WaitInfoIntField
 	TotalWaiting  WaitInfoIntField
 }
@@ -29,32 +44,63 @@ func (info *WaitInfo) validateCurrentTicketNumber() bool {
 	return info.CurrentNumber > 0
 }
 
-// parse the wait information from the API response body
-func parseWaitInfoFromResponse(info string) (WaitInfo, error) {
-	var output WaitInfo = WaitInfo{RawData: info}
+// parseWaitInfoFromResponse parses the wait information from the body of a handler response,
+// formatted as "header|current number|groups waiting". Surrounding whitespace, a byte order
+// mark and fields after the third one are ignored. Synthetic sample responses along with the
+// expected outcome of each are kept in testdata/responses and checked by the tests, see expected.json.
+//
+// Returns:
+//   - WaitInfo: The parsed wait info, with -1 for the fields that are not numbers
+//   - error: ErrUpstreamClosed, ErrMalformed or ErrNoTicketYet, possibly wrapped, nil otherwise.
+//     The wait info is filled as far as possible along with ErrUpstreamClosed and ErrNoTicketYet.
+func parseWaitInfoFromResponse(body string) (WaitInfo, error) {
+	output := WaitInfo{RawData: body, CurrentNumber: -1, TotalWaiting: -1}
+
+	body = strings.TrimSpace(strings.TrimPrefix(body, "\uFEFF"))
+	if body == "" {
+		return output, fmt.Errorf("%w: empty response", ErrUpstreamClosed)
+	}
+	if strings.HasPrefix(body, "<") {
+		return output, fmt.Errorf("%w: received an HTML page", ErrMalformed)
+	}
 
-	parts := strings.Split(info, "|")
-	if len(parts) != 3 {
-		return output, fmt.Errorf("invalid format: expected 3 parts, got %d", len(parts))
+	parts := strings.Split(body, "|")
+	if len(parts) < 3 {
+		return output, fmt.Errorf("%w: expected 3 fields, got %d", ErrMalformed, len(parts))
 	}
+	output.Header = strings.TrimSpace(parts[0])
 
-	// skip the first part, which is unless data
+	currentNumber, currentErr := parseWaitInfoIntField(parts[1])
+	totalWaiting, waitingErr := parseWaitInfoIntField(parts[2])
+	output.CurrentNumber, output.TotalWaiting = currentNumber, totalWaiting
 
-	// chunk 2, represene the current ticket number
-	currentNumber, err := strconv.Atoi(parts[1])
-	if err != nil {
-		output.CurrentNumber = -1
-	} else {
-		output.CurrentNumber = WaitInfoIntField(currentNumber)
+	switch {
+	case currentErr != nil && waitingErr != nil:
+		if isPlaceholder(parts[1]) && isPlaceholder(parts[2]) {
+			return output, fmt.Errorf("%w: %q", ErrUpstreamClosed, body)
+		}
+		return output, fmt.Errorf("%w: %q", ErrMalformed, body)
+	case waitingErr != nil && !isPlaceholder(parts[2]):
+		return output, fmt.Errorf("%w: invalid groups waiting %q", ErrMalformed, parts[2])
+	case currentErr != nil && !isPlaceholder(parts[1]):
+		return output, fmt.Errorf("%w: invalid current number %q", ErrMalformed, parts[1])
+	case currentNumber <= 0:
+		return output, ErrNoTicketYet
 	}
+	return output, nil
+}
 
-	// chunk 3, represent the count of waiting tickets
-	totalWaitingCount, err := strconv.Atoi(parts[2])
-	if err != nil {
-		output.TotalWaiting = -1
-	} else {
-		output.TotalWaiting = WaitInfoIntField(totalWaitingCount)
+// parseWaitInfoIntField parses a numeric field, returning -1 along with the error if it is not a number.
+func parseWaitInfoIntField(field string) (WaitInfoIntField, error) {
+	value, err := strconv.Atoi(strings.TrimSpace(field))
+	if err != nil || value < 0 {
+		return -1, fmt.Errorf("invalid number %q", field)
 	}
+	return WaitInfoIntField(value), nil
+}
 
-	return output, nil
+// isPlaceholder reports whether a field is a placeholder the handler answers instead of a number,
+// such as "----" or an empty field.
+func isPlaceholder(field string) bool {
+	return strings.Trim(strings.TrimSpace(field), "-") == ""
 }
@@ -0,0 +1,86 @@
+package gido
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// expectedWaitInfo is the expected outcome of parsing a response of testdata/responses.
+type expectedWaitInfo struct {
+	Header        string `json:"header"`
+	CurrentNumber int    `json:"current_number"`
+	TotalWaiting  int    `json:"total_waiting"`
+	// Error is the name of the sentinel error the parsing fails with, empty on success.
+	Error string `json:"error"`
+}
+
+var waitInfoErrors = map[string]error{
+	"ErrUpstreamClosed": ErrUpstreamClosed,
+	"ErrMalformed":      ErrMalformed,
+	"ErrNoTicketYet":    ErrNoTicketYet,
+}
+
+func TestParseWaitInfoFromResponse(t *testing.T) {
+	dir := filepath.Join("testdata", "responses")
+	data, err := os.ReadFile(filepath.Join(dir, "expected.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expected map[string]expectedWaitInfo
+	if err := json.Unmarshal(data, &expected); err != nil {
+		t.Fatalf("failed to parse expected.json: %v", err)
+	}
+
+	// every response must have an expected outcome
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if name := entry.Name(); name != "expected.json" && name != "README.md" {
+			if _, exists := expected[name]; !exists {
+				t.Errorf("%s has no expected outcome in expected.json", name)
+			}
+		}
+	}
+
+	for name, want := range expected {
+		t.Run(name, func(t *testing.T) {
+			body, err := os.ReadFile(filepath.Join(dir, name))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			info, err := parseWaitInfoFromResponse(string(body))
+			if want.Error == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			} else {
+				wantErr, known := waitInfoErrors[want.Error]
+				if !known {
+					t.Fatalf("unknown error %q in expected.json", want.Error)
+				}
+				if !errors.Is(err, wantErr) {
+					t.Fatalf("error %v, want %s", err, want.Error)
+				}
+			}
+
+			if info.Header != want.Header {
+				t.Errorf("header %q, want %q", info.Header, want.Header)
+			}
+			if int(info.CurrentNumber) != want.CurrentNumber {
+				t.Errorf("current number %d, want %d", info.CurrentNumber, want.CurrentNumber)
+			}
+			if int(info.TotalWaiting) != want.TotalWaiting {
+				t.Errorf("total waiting %d, want %d", info.TotalWaiting, want.TotalWaiting)
+			}
+			if info.RawData != string(body) {
+				t.Errorf("raw data %q, want the response body", info.RawData)
+			}
+		})
+	}
+}
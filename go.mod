@@ -3,9 +3,9 @@ module github.com/SDxBacon/gido-guardian-bot
 go 1.23.5
 
 require (
-	github.com/SDxBacon/go-utils/discord v0.1.0
 	github.com/bwmarrin/discordgo v0.29.0
 	github.com/joho/godotenv v1.5.1
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
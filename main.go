@@ -1,10 +1,16 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"log"
+	"log/slog"
 	"os"
+	"time"
 
 	"github.com/SDxBacon/gido-guardian-bot/bot"
+	"github.com/SDxBacon/gido-guardian-bot/config"
+	"github.com/SDxBacon/gido-guardian-bot/gido"
 	"github.com/joho/godotenv"
 )
 
@@ -15,9 +21,111 @@ func main() {
 		log.Println("Error loading .env file")
 	}
 
-	// get the bot token from the environment
-	token := os.Getenv("BOT_TOKEN")
+	// load the configuration file, overridden by the environment and the flags
+	cfg, printConfig, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		log.Fatalf("Error loading config: %v", err)
+	}
 
-	bot.Token = token
+	if printConfig {
+		if err := cfg.Redacted().Write(os.Stdout); err != nil {
+			log.Fatalf("Error printing config: %v", err)
+		}
+		if err := cfg.Validate(); err != nil {
+			log.Fatalf("Invalid config:\n%v", err)
+		}
+		return
+	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Invalid config:\n%v", err)
+	}
+
+	configure(cfg)
 	bot.Run()
 }
+
+// configure sets up the logging, the stores and the bot from a validated configuration.
+func configure(cfg *config.Config) {
+	// log at the configured level: the failures are logged at the warn and error levels, and
+	// the log package, writing the informational messages at the info level, goes through slog
+	level, err := cfg.Log.SlogLevel()
+	if err != nil {
+		log.Fatalf("Error parsing log level: %v", err)
+	}
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level})))
+
+	// the time zone of the stores, for the date sent to the handler and their opening hours
+	if cfg.Upstream.TimeZone != "" {
+		gido.DefaultLocation, err = time.LoadLocation(cfg.Upstream.TimeZone)
+		if err != nil {
+			log.Fatalf("Error loading time zone: %v", err)
+		}
+	}
+
+	// pick the wait info source: a replay file, a custom endpoint, or the official one
+	newSource := gido.HTTPSourceFactory()
+	if cfg.Upstream.ReplayFile != "" {
+		newSource, err = gido.ReplaySourceFactory(cfg.Upstream.ReplayFile)
+		if err != nil {
+			log.Fatalf("Error loading replay file: %v", err)
+		}
+	} else if cfg.Upstream.BaseURL != "" {
+		newSource = gido.HTTPSourceFactory(gido.WithBaseURL(cfg.Upstream.BaseURL))
+	}
+
+	// retry the failed fetches with a jittered exponential backoff
+	retryOpts, err := gido.ParseRetryOptions(cfg.Upstream.Retries)
+	if err != nil {
+		log.Fatalf("Error parsing retries: %v", err)
+	}
+	newSource = gido.RetrySourceFactory(newSource, retryOpts...)
+
+	// pause polling while the upstream is down, and poll at the configured intervals
+	breakerOpt, err := gido.ParseBreakerOption(cfg.Upstream.Breaker)
+	if err != nil {
+		log.Fatalf("Error parsing breaker: %v", err)
+	}
+	pollerOpts := []gido.PollerOption{
+		breakerOpt,
+		gido.WithPollerInterval(time.Duration(cfg.Polling.Interval)),
+		gido.WithPollerMinInterval(time.Duration(cfg.Polling.MinInterval)),
+	}
+
+	bot.Stores = gido.NewStoreRegistry(newSource, pollerOpts...)
+	for _, store := range cfg.Stores {
+		if err := bot.Stores.Register(store); err != nil {
+			log.Fatalf("Error registering store: %v", err)
+		}
+	}
+
+	bot.AdaptivePolling, bot.AdaptiveInterval, err = cfg.Polling.AdaptiveInterval()
+	if err != nil {
+		log.Fatalf("Error parsing adaptive polling: %v", err)
+	}
+	bot.ResetAction, err = gido.ParseResetAction(cfg.Watches.OnReset)
+	if err != nil {
+		log.Fatalf("Error parsing reset action: %v", err)
+	}
+	bot.MaxWatchesPerUser = cfg.Watches.MaxPerUser
+	bot.MaxWatchDuration = time.Duration(cfg.Watches.MaxDuration)
+
+	bot.WatchesFile = cfg.Storage.WatchesFile
+	bot.PreferencesFile = cfg.Storage.PreferencesFile
+	bot.HistoryFile = cfg.Storage.HistoryFile
+
+	if cfg.Permissions.AdminRoles != nil {
+		bot.AdminRoles = cfg.Permissions.AdminRoles
+	}
+	if cfg.Discord.GuildLanguages != nil {
+		bot.GuildLanguages = cfg.Discord.GuildLanguages
+	}
+	if cfg.Discord.EphemeralResponses != nil {
+		bot.EphemeralResponses = cfg.Discord.EphemeralResponses
+	}
+
+	bot.Token = cfg.Discord.Token
+	bot.GuildIDs = cfg.Discord.GuildIDs
+}